@@ -0,0 +1,453 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// A flag that can be given several times, e.g. '--bin a --bin b'
+type stringList []string
+
+func (list *stringList) String() string {
+	return strings.Join(*list, ",")
+}
+
+func (list *stringList) Set(value string) error {
+	*list = append(*list, value)
+	return nil
+}
+
+// The flags of 'add' and 'edit' that set the entries of a tool
+type ToolFlags struct {
+	Owner        *string
+	Repository   *string
+	Host         *string
+	LinuxAsset   *string
+	WindowsAsset *string
+	Description  *string
+	Binaries     stringList
+	Tags         stringList
+}
+
+func addToolFlags(flagSet *flag.FlagSet) *ToolFlags {
+	flags := ToolFlags{
+		Owner:        flagSet.String("owner", "", "Owner of the repository"),
+		Repository:   flagSet.String("repo", "", "Name of the repository"),
+		Host:         flagSet.String("host", "", "Domain of a Gitea-compatible forge, empty for GitHub"),
+		LinuxAsset:   flagSet.String("linux", "", "Suffix of the asset name on Linux"),
+		WindowsAsset: flagSet.String("windows", "", "Suffix of the asset name on Windows"),
+		Description:  flagSet.String("description", "", "Short description of the tool"),
+	}
+	flagSet.Var(&flags.Binaries, "bin", "Binary to install, 'name' or 'name=rename_to', can be given several times")
+	flagSet.Var(&flags.Tags, "tag", "Tag of the tool, can be given several times")
+
+	return &flags
+}
+
+// Parses binaries given as 'name' or 'name=rename_to'
+func parseBinaries(values []string) []Binary {
+	var result []Binary
+	for _, value := range values {
+		for _, entry := range strings.Split(value, ",") {
+			name, renameTo, _ := strings.Cut(strings.TrimSpace(entry), "=")
+			if name != "" {
+				result = append(result, Binary{Name: name, RenameTo: renameTo})
+			}
+		}
+	}
+
+	return result
+}
+
+func formatBinaries(binaries []Binary) string {
+	entries := make([]string, len(binaries))
+	for i, binary := range binaries {
+		entries[i] = binary.Name
+		if binary.RenameTo != "" {
+			entries[i] += "=" + binary.RenameTo
+		}
+	}
+
+	return strings.Join(entries, ", ")
+}
+
+var toolFlagNames = []string{"owner", "repo", "host", "linux", "windows", "description", "bin", "tag"}
+
+// Whether any of the flags that set entries of the tool was given
+func (flags *ToolFlags) given(flagSet *flag.FlagSet) bool {
+	result := false
+	flagSet.Visit(func(f *flag.Flag) {
+		if slices.Contains(toolFlagNames, f.Name) {
+			result = true
+		}
+	})
+
+	return result
+}
+
+// Sets the entries of the tool for the flags given on the command line
+func (flags *ToolFlags) apply(flagSet *flag.FlagSet, tool *Tool) {
+	flagSet.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "owner":
+			tool.Owner = *flags.Owner
+		case "repo":
+			tool.Repository = *flags.Repository
+		case "host":
+			tool.Host = *flags.Host
+		case "linux":
+			tool.LinuxAsset = *flags.LinuxAsset
+		case "windows":
+			tool.WindowsAsset = *flags.WindowsAsset
+		case "description":
+			tool.Description = *flags.Description
+		case "bin":
+			tool.Binaries = parseBinaries(flags.Binaries)
+		case "tag":
+			tool.Tags = flags.Tags
+		}
+	})
+}
+
+// Writes the entries that 'add' and 'edit' can change to the entry of the
+// tool in the configuration file, all other entries are kept as they are
+func setRawToolEntries(rawTool map[string]any, tool *Tool) {
+	rawTool["owner"] = tool.Owner
+	rawTool["repository"] = tool.Repository
+	rawTool["linux_asset"] = tool.LinuxAsset
+	rawTool["windows_asset"] = tool.WindowsAsset
+	rawTool["description"] = tool.Description
+
+	binaries := make([]any, len(tool.Binaries))
+	for i, binary := range tool.Binaries {
+		binaries[i] = map[string]any{"name": binary.Name, "rename_to": binary.RenameTo}
+	}
+	rawTool["binaries"] = binaries
+
+	delete(rawTool, "host")
+	if tool.Host != "" {
+		rawTool["host"] = tool.Host
+	}
+
+	delete(rawTool, "tags")
+	if len(tool.Tags) > 0 {
+		tags := make([]any, len(tool.Tags))
+		for i, tag := range tool.Tags {
+			tags[i] = tag
+		}
+		rawTool["tags"] = tags
+	}
+}
+
+// Set by the global option '--yes', which confirms all questions and fails
+// instead of waiting for input, for running tooli in scripts
+var assumeYes bool
+
+// Removes '--yes' and its aliases from the global options before the command
+// and returns the rest
+func parseAssumeYes(args []string) []string {
+	result := make([]string, 0, len(args))
+	for _, arg := range args {
+		switch arg {
+		case "-y", "--yes", "--non-interactive":
+			assumeYes = true
+		default:
+			result = append(result, arg)
+		}
+	}
+
+	return result
+}
+
+// Reads answers to questions from the terminal
+type Prompter struct {
+	reader *bufio.Reader
+}
+
+func newPrompter() *Prompter {
+	return &Prompter{reader: bufio.NewReader(os.Stdin)}
+}
+
+// Asks for a value, an empty answer keeps the current value
+func (prompter *Prompter) ask(question string, current string) (string, error) {
+	if assumeYes {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return "", fmt.Errorf("An answer is required for '%s', but '--yes' does not allow asking. Pass the values as options instead.", question)
+	}
+
+	if current != "" {
+		fmt.Printf("%s [%s]: ", question, current)
+	} else {
+		fmt.Printf("%s: ", question)
+	}
+
+	answer, err := prompter.reader.ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && answer != "") {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return "", errors.New("No answer was given.")
+	}
+
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return current, nil
+	}
+
+	return answer, nil
+}
+
+// Asks a yes/no question, an empty answer is yes
+func (prompter *Prompter) confirm(question string) (bool, error) {
+	if assumeYes {
+		return true, nil
+	}
+
+	answer, err := prompter.ask(question+" [Y/n]", "")
+	if err != nil {
+		return false, err
+	}
+
+	return answer == "" || answer[0] == 'y' || answer[0] == 'Y', nil
+}
+
+// Asks for the entries of a tool, pre-filled with its current values
+func (prompter *Prompter) askTool(tool *Tool) error {
+	var err error
+	questions := []struct {
+		question string
+		value    *string
+	}{
+		{"Owner of the repository", &tool.Owner},
+		{"Name of the repository", &tool.Repository},
+		{"Suffix of the asset on Linux", &tool.LinuxAsset},
+		{"Suffix of the asset on Windows", &tool.WindowsAsset},
+		{"Description", &tool.Description},
+	}
+
+	for _, entry := range questions {
+		*entry.value, err = prompter.ask(entry.question, *entry.value)
+		if err != nil {
+			return err
+		}
+	}
+
+	binaries, err := prompter.ask("Binaries, separated by commas, as 'name' or 'name=rename_to'", formatBinaries(tool.Binaries))
+	if err != nil {
+		return err
+	}
+	tool.Binaries = parseBinaries([]string{binaries})
+
+	return nil
+}
+
+// Returns the problems of a tool as a single error
+func getToolError(name string, tool *Tool) error {
+	problems := getToolProblems(name, tool)
+	if len(problems) == 0 {
+		return nil
+	}
+
+	prefix := fmt.Sprintf("Tool '%s': ", name)
+	for i := range problems {
+		problems[i] = strings.TrimPrefix(problems[i], prefix)
+	}
+
+	//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+	return fmt.Errorf("Tool '%s' is not valid: %s.", name, strings.Join(problems, ", "))
+}
+
+// Prints the entries of a tool that 'add' would add
+func printToolProposal(name string, tool *Tool) {
+	fmt.Printf("Tool:        %s\n", name)
+	fmt.Printf("Repository:  %s\n", getToolLink(tool))
+	fmt.Printf("Linux:       %s\n", tool.LinuxAsset)
+	fmt.Printf("Windows:     %s\n", tool.WindowsAsset)
+	fmt.Printf("Binaries:    %s\n", formatBinaries(tool.Binaries))
+	fmt.Printf("Description: %s\n", tool.Description)
+}
+
+// Checks a tool that is about to be written to the configuration, with the
+// configuration's defaults applied to it
+func (config *Configuration) checkNewTool(name string, tool Tool) error {
+	check := Configuration{Defaults: config.Defaults, Tools: map[string]Tool{name: tool}}
+	check.applyDefaults()
+
+	tool = check.Tools[name]
+
+	return getToolError(name, &tool)
+}
+
+// Adds a tool to the configuration. Without flags, the entries are asked for
+// interactively, starting from the catalog entry of the same name if there is
+// one. Given as 'owner/repository', the entries are derived from the
+// repository and its latest release and only need to be confirmed.
+func addTool(configLocation *string, name string, flagSet *flag.FlagSet, flags *ToolFlags, downloadTimeout int) error {
+	config, err := loadConfiguration(*configLocation)
+	if err != nil {
+		return err
+	}
+
+	var tool Tool
+	owner, repository, isRepository := strings.Cut(name, "/")
+	if isRepository {
+		name = strings.ToLower(repository)
+		tool.Owner = owner
+		tool.Repository = repository
+	}
+
+	if _, found := config.Tools[name]; found {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("Tool '%s' already exists in the configuration.", name)
+	}
+
+	catalogTool, inCatalog := getCatalog()[name]
+
+	switch {
+	case isRepository:
+		flags.apply(flagSet, &tool)
+
+		config.excludeRegexes, err = compileExcludeAssets(config.getExcludeAssets())
+		if err != nil {
+			return err
+		}
+
+		downloader, err := newDownloader(downloadTimeout, &config)
+		if err != nil {
+			return err
+		}
+
+		err = discoverTool(&downloader, &tool, &config)
+		if err != nil {
+			return err
+		}
+
+		if !flags.given(flagSet) {
+			printToolProposal(name, &tool)
+
+			prompter := newPrompter()
+			confirmed, err := prompter.confirm("Add the tool with these entries?")
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				err = prompter.askTool(&tool)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	case flags.given(flagSet):
+		if inCatalog && *flags.Owner == "" {
+			tool = catalogTool
+		}
+		flags.apply(flagSet, &tool)
+	default:
+		prompter := newPrompter()
+
+		useCatalog := false
+		if inCatalog {
+			useCatalog, err = prompter.confirm(fmt.Sprintf("Use '%s' (%s) from the catalog?", name, getToolLink(&catalogTool)))
+			if err != nil {
+				return err
+			}
+		}
+
+		if useCatalog {
+			tool = catalogTool
+		} else {
+			err = prompter.askTool(&tool)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(tool.Binaries) == 0 {
+		tool.Binaries = []Binary{{Name: name}}
+	}
+
+	err = config.checkNewTool(name, tool)
+	if err != nil {
+		return err
+	}
+
+	raw, err := readRawConfiguration(*configLocation)
+	if err != nil {
+		return err
+	}
+
+	err = addRawTools(raw, map[string]Tool{name: tool})
+	if err != nil {
+		return err
+	}
+
+	err = writeRawConfiguration(*configLocation, raw)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Added tool '%s', install it with 'tooli install %s'.\n", name, name)
+
+	return nil
+}
+
+// Changes the entries of a tool, either with the flags of 'add' or by asking
+// for each entry with the current value as the default
+func editTool(configLocation *string, name string, flagSet *flag.FlagSet, flags *ToolFlags) error {
+	config, err := loadConfiguration(*configLocation)
+	if err != nil {
+		return err
+	}
+
+	if _, found := config.Tools[name]; !found {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("Tool '%s' not found in configuration.", name)
+	}
+
+	raw, err := readRawConfiguration(*configLocation)
+	if err != nil {
+		return err
+	}
+
+	rawTool, err := getRawTool(raw, name)
+	if err != nil {
+		return err
+	}
+
+	tool, err := decodeRawTool(rawTool)
+	if err != nil {
+		return err
+	}
+
+	if flags.given(flagSet) {
+		flags.apply(flagSet, &tool)
+	} else {
+		err = newPrompter().askTool(&tool)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = config.checkNewTool(name, tool)
+	if err != nil {
+		return err
+	}
+
+	setRawToolEntries(rawTool, &tool)
+
+	err = writeRawConfiguration(*configLocation, raw)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Changed tool '%s'.\n", name)
+
+	return nil
+}
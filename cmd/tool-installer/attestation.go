@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Keyless signatures and GitHub artifact attestations are signed with
+// short-lived certificates, whose verification needs the certificate authority
+// and transparency log of a sigstore instance. It is therefore left to the
+// 'cosign' and 'gh' CLIs.
+
+// The issuer of the certificates of GitHub Actions workflows, which sign most
+// keyless signatures of releases
+const githubActionsIssuer = "https://token.actions.githubusercontent.com"
+
+// Runs a CLI that verifies a signature, its output is only shown on failure
+func runVerifier(name string, arguments ...string) error {
+	if _, err := exec.LookPath(name); err != nil {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("'%s' is needed for the verification, but it was not found in the PATH", name)
+	}
+
+	output, err := exec.Command(name, arguments...).CombinedOutput()
+	if err != nil {
+		message := strings.TrimSpace(string(output))
+		if message == "" {
+			return err
+		}
+		return errors.New(message)
+	}
+
+	return nil
+}
+
+// Writes the content into the directory under the given name
+func writeVerifierFile(directory string, name string, content io.Reader) (string, error) {
+	filePath := filepath.Join(directory, filepath.Base(name))
+
+	return filePath, writeFile(filePath, content, 0644)
+}
+
+// Verifies the keyless cosign bundle of the asset, whose certificate has to
+// be issued by the tool's 'cosign_issuer' to an identity matching its
+// 'cosign_identity'
+func verifyKeylessCosignAsset(source Source, release *Release, asset *Asset, data *io.SectionReader, tool *Tool) error {
+	bundleAsset := findCosignAsset(release, asset, []string{".sigstore.json", ".bundle"})
+	if bundleAsset == nil {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("A cosign identity is configured, but the release contains no bundle for '%s'.", asset.Name)
+	}
+
+	content, err := readAsset(source, bundleAsset)
+	if err != nil {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("Could not download the bundle '%s': %v", bundleAsset.Name, err)
+	}
+
+	directory, err := os.MkdirTemp("", "tooli-verify-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(directory)
+
+	assetPath, err := writeVerifierFile(directory, asset.Name, readFromStart(data))
+	if err != nil {
+		return err
+	}
+
+	bundlePath, err := writeVerifierFile(directory, bundleAsset.Name, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+
+	issuer := tool.CosignIssuer
+	if issuer == "" {
+		issuer = githubActionsIssuer
+	}
+
+	arguments := []string{"verify-blob", "--bundle", bundlePath, "--certificate-identity-regexp", tool.CosignIdentity, "--certificate-oidc-issuer", issuer}
+	if strings.HasSuffix(bundleAsset.Name, ".sigstore.json") {
+		arguments = append(arguments, "--new-bundle-format")
+	}
+
+	err = runVerifier("cosign", append(arguments, assetPath)...)
+	if err != nil {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("Could not verify '%s' with '%s': %v", asset.Name, bundleAsset.Name, err)
+	}
+
+	logInfo("Verified the keyless cosign signature of '%s'.", asset.Name)
+	return nil
+}
+
+// Verifies that the asset has a GitHub artifact attestation of the tool's
+// repository. The attestations are fetched by 'gh', which uses its own login
+// or the GITHUB_TOKEN environment variable.
+func verifyAttestation(asset *Asset, data *io.SectionReader, tool *Tool) error {
+	if !isGithubHost(tool.Host) || tool.Owner == "" || tool.Repository == "" {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return errors.New("Attestations can only be verified for tools from GitHub repositories.")
+	}
+
+	if offlineMode {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("The attestation of '%s' cannot be verified offline.", asset.Name)
+	}
+
+	directory, err := os.MkdirTemp("", "tooli-verify-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(directory)
+
+	assetPath, err := writeVerifierFile(directory, asset.Name, readFromStart(data))
+	if err != nil {
+		return err
+	}
+
+	err = runVerifier("gh", "attestation", "verify", assetPath, "--repo", tool.Owner+"/"+tool.Repository)
+	if err != nil {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("Could not verify the attestation of '%s': %v", asset.Name, err)
+	}
+
+	logInfo("Verified the attestation of '%s'.", asset.Name)
+	return nil
+}
@@ -0,0 +1,156 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// A file besides the binaries that is installed into its own directory
+type AuxiliaryFile struct {
+	// Name of the file in the archive, like the name of a binary
+	Pattern   string
+	Directory string
+	// Name to install the file as, based on its name in the archive
+	getName func(string) string
+	// Whether the pattern can match several files, e.g. '*.1'
+	multiple bool
+}
+
+// Returns the index of the auxiliary file matching the archive entry, its
+// directory and the name to install it as, or -1 if there is none
+func findAuxiliaryFile(fullName string, auxiliary []AuxiliaryFile) (int, string, string) {
+	for i, file := range auxiliary {
+		if matchesBinaryName(file.Pattern, fullName) {
+			return i, file.Directory, file.getName(path.Base(fullName))
+		}
+	}
+
+	return -1, "", ""
+}
+
+func countSingleFiles(auxiliary []AuxiliaryFile) int {
+	result := 0
+	for _, file := range auxiliary {
+		if !file.multiple {
+			result++
+		}
+	}
+
+	return result
+}
+
+// Returns where completions are installed by default, the directories are
+// loaded automatically by bash-completion and fish. Zsh needs the directory
+// to be added to its 'fpath'.
+func getDefaultCompletionDirectories() map[string]string {
+	result := make(map[string]string)
+	if runtime.GOOS == "windows" {
+		return result
+	}
+
+	if dataDirectory, err := getXdgDirectory("XDG_DATA_HOME", filepath.Join(".local", "share")); err == nil {
+		result["bash"] = filepath.Join(dataDirectory, "bash-completion", "completions")
+		result["zsh"] = filepath.Join(dataDirectory, "zsh", "site-functions")
+	}
+
+	if configDirectory, err := getXdgDirectory("XDG_CONFIG_HOME", ".config"); err == nil {
+		result["fish"] = filepath.Join(configDirectory, "fish", "completions")
+	}
+
+	return result
+}
+
+func (config *Configuration) getCompletionDirectory(shell string) string {
+	if directory, found := config.CompletionDirectories[shell]; found {
+		return expandPath(directory)
+	}
+
+	return getDefaultCompletionDirectories()[shell]
+}
+
+// Shells expect completions under a name based on the command, e.g. 'rg' for
+// bash, '_rg' for zsh and 'rg.fish' for fish
+func getCompletionName(shell string, fileName string) string {
+	switch shell {
+	case "bash":
+		return strings.TrimSuffix(strings.TrimSuffix(fileName, ".bash"), ".bash-completion")
+	case "zsh":
+		if !strings.HasPrefix(fileName, "_") {
+			return "_" + strings.TrimSuffix(fileName, ".zsh")
+		}
+	case "fish":
+		if !strings.HasSuffix(fileName, ".fish") {
+			return fileName + ".fish"
+		}
+	}
+
+	return fileName
+}
+
+func getAuxiliaryFiles(tool *Tool, config *Configuration) []AuxiliaryFile {
+	var result []AuxiliaryFile
+
+	shells := make([]string, 0, len(tool.Completions))
+	for shell := range tool.Completions {
+		shells = append(shells, shell)
+	}
+	sort.Strings(shells)
+
+	for _, shell := range shells {
+		directory := config.getCompletionDirectory(shell)
+		if directory == "" {
+			continue
+		}
+
+		result = append(result, AuxiliaryFile{
+			Pattern:   tool.Completions[shell],
+			Directory: directory,
+			getName:   func(fileName string) string { return getCompletionName(shell, fileName) },
+		})
+	}
+
+	if manDirectory := config.getManDirectory(); manDirectory != "" {
+		for _, pattern := range tool.ManPages {
+			result = append(result, AuxiliaryFile{
+				Pattern:   pattern,
+				Directory: manDirectory,
+				getName:   getManPageName,
+				multiple:  strings.ContainsAny(pattern, "*?["),
+			})
+		}
+	}
+
+	return result
+}
+
+// Man pages like 'rg.1' or 'rg.1.gz' go into the directory of their section, e.g. 'man1'
+func getManPageName(fileName string) string {
+	section := path.Ext(strings.TrimSuffix(fileName, ".gz"))
+	if len(section) < 2 {
+		return fileName
+	}
+
+	return "man" + section[1:2] + "/" + fileName
+}
+
+func (config *Configuration) getManDirectory() string {
+	if config.ManDirectory != "" {
+		return expandPath(config.ManDirectory)
+	}
+
+	if runtime.GOOS == "windows" {
+		return ""
+	}
+
+	dataDirectory, err := getXdgDirectory("XDG_DATA_HOME", filepath.Join(".local", "share"))
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dataDirectory, "man")
+}
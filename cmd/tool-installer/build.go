@@ -0,0 +1,223 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+func newShellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+
+	return exec.Command("sh", "-c", command)
+}
+
+// Extracts every regular file of a .tar.gz archive into outputPath
+func extractAllTarGz(reader io.Reader, outputPath string) error {
+	gzipReader, err := gzip.NewReader(reader)
+	if err != nil {
+		return err
+	}
+	defer gzipReader.Close()
+
+	tarReader := tar.NewReader(gzipReader)
+
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if !filepath.IsLocal(header.Name) {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return fmt.Errorf("The archive contains the invalid path '%s'.", header.Name)
+		}
+
+		filePath := filepath.Join(outputPath, header.Name)
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(filePath, 0755)
+		case tar.TypeReg:
+			err = writeFile(filePath, tarReader, os.FileMode(header.Mode)&0777)
+			if err == nil {
+				err = os.Chtimes(filePath, header.ModTime, header.ModTime)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeFile(filePath string, reader io.Reader, mode os.FileMode) error {
+	err := os.MkdirAll(filepath.Dir(filePath), 0755)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// The mode only applies to new files, existing ones might have another
+	file.Chmod(mode)
+
+	_, err = io.Copy(file, reader)
+
+	return err
+}
+
+// Source archives usually contain a single top-level directory, which is where
+// the build has to run
+func getSourceRoot(directory string) string {
+	entries, err := os.ReadDir(directory)
+	if err == nil && len(entries) == 1 && entries[0].IsDir() {
+		return filepath.Join(directory, entries[0].Name())
+	}
+
+	return directory
+}
+
+// Finds the files produced by the build and copies them to the output path. If
+// a name occurs multiple times, the most recently modified file is the one the
+// build produced.
+func installBuiltBinaries(buildDirectory string, binaries []Binary, outputPath *string) ([]string, error) {
+	found := make(map[int]string)
+	modified := make(map[int]time.Time)
+	targets := make(map[int]string)
+
+	err := filepath.WalkDir(buildDirectory, func(filePath string, entry os.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		index, target := findBinary(filepath.ToSlash(filePath), binaries)
+		if index < 0 {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		if previous, ok := modified[index]; !ok || info.ModTime().After(previous) {
+			found[index] = filePath
+			modified[index] = info.ModTime()
+			targets[index] = target
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var installed []string
+	for i, binary := range binaries {
+		target := targets[i]
+
+		builtPath, ok := found[i]
+		if !ok {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return nil, fmt.Errorf("The build did not produce the binary '%s'.", binary.Name)
+		}
+
+		file, err := os.Open(builtPath)
+		if err != nil {
+			return nil, err
+		}
+
+		filePath, err := prepareOutputFile(*outputPath, target)
+		if err == nil {
+			err = writeFile(filePath, file, 0755)
+		}
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		installed = append(installed, target)
+	}
+
+	return installed, nil
+}
+
+// Downloads the source code of the release, runs the tool's build_command in
+// it and installs the resulting binaries, returning their names
+func buildTool(source Source, release *Release, tool *Tool, outputPath *string) ([]string, error) {
+	if len(tool.Binaries) == 0 {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return nil, errors.New("No binaries to install provided for the build.")
+	}
+
+	archive, err := os.CreateTemp("", "tooli-*")
+	if err != nil {
+		return nil, err
+	}
+	defer removeTempFile(archive)
+
+	err = source.DownloadSourceArchive(release, archive)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = archive.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	buildDirectory, err := os.MkdirTemp("", "tooli-build-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(buildDirectory)
+
+	err = extractAllTarGz(archive, buildDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceRoot := getSourceRoot(buildDirectory)
+
+	logInfo("Running build command '%s'.", tool.BuildCommand)
+
+	// Other tools are installed at the same time, so the output of the build
+	// is only shown if it fails
+	cmd := newShellCommand(tool.BuildCommand)
+	cmd.Dir = sourceRoot
+	cmd.Env = append(os.Environ(), "TOOLI_VERSION="+release.TagName)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		message := strings.TrimSpace(string(output))
+		if message == "" {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return nil, fmt.Errorf("The build command failed: %v", err)
+		}
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return nil, fmt.Errorf("The build command failed: %v\n%s", err, message)
+	}
+
+	return installBuiltBinaries(sourceRoot, tool.Binaries, outputPath)
+}
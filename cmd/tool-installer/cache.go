@@ -6,10 +6,48 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"sync"
 )
 
+// Details about an installed tool beyond its version
+type InstalledTool struct {
+	Asset  string `json:"asset,omitempty"`
+	Sha256 string `json:"sha256,omitempty"`
+	// Names of the installed files, relative to the installation directory,
+	// or absolute for auxiliary files like completions
+	Files []string `json:"files,omitempty"`
+	// SHA-256 digests of the installed files, to detect later modifications
+	FileHashes map[string]string `json:"file_sha256,omitempty"`
+}
+
 type Cache struct {
-	Tools map[string]string `json:"tools"`
+	Tools     map[string]string        `json:"tools"`
+	Installed map[string]InstalledTool `json:"installed,omitempty"`
+	// Tools that are excluded from updates
+	Held map[string]bool `json:"held,omitempty"`
+	// Origins like 'owner/repo' the user allowed downloads from
+	Trusted      map[string]bool `json:"trusted,omitempty"`
+	RequireTrust bool            `json:"require_trust,omitempty"`
+}
+
+// Guards the cache while tools are installed in parallel
+var cacheMutex sync.Mutex
+
+// Returns the installed version of a tool
+func (cache *Cache) getVersion(name string) (string, bool) {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	version, found := cache.Tools[name]
+	return version, found
+}
+
+func getInstalledFilePath(installDirectory string, file string) string {
+	if filepath.IsAbs(file) {
+		return file
+	}
+
+	return filepath.Join(installDirectory, file)
 }
 
 func (cache *Cache) writeCache() error {
@@ -33,7 +71,7 @@ func (cache *Cache) writeCache() error {
 }
 
 func getCache() (Cache, error) {
-	result := Cache{Tools: make(map[string]string)}
+	result := Cache{Tools: make(map[string]string), Installed: make(map[string]InstalledTool), Held: make(map[string]bool), Trusted: make(map[string]bool)}
 
 	filePath, err := getCacheFilePath()
 	if err != nil {
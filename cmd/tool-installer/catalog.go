@@ -0,0 +1,262 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// The community-maintained catalog that 'catalog update' downloads by default
+const defaultCatalogUrl = "https://raw.githubusercontent.com/ageh/tool-installer/main/catalog.json"
+
+// Format of the catalog file, the tools use the same entries as in the configuration
+type Catalog struct {
+	Tools map[string]Tool `json:"tools"`
+}
+
+func newCatalogTool(owner string, repository string, linuxAsset string, windowsAsset string, description string, binaries ...string) Tool {
+	tool := Tool{Owner: owner, Repository: repository, LinuxAsset: linuxAsset, WindowsAsset: windowsAsset, Description: description}
+	for _, name := range binaries {
+		tool.Binaries = append(tool.Binaries, Binary{Name: name})
+	}
+
+	return tool
+}
+
+// Built-in definitions of popular tools, so they can be added without looking
+// up their assets
+var knownTools = map[string]Tool{
+	"age":        newCatalogTool("FiloSottile", "age", "linux-amd64.tar.gz", "windows-amd64.zip", "File encryption", "age", "age-keygen"),
+	"bat":        newCatalogTool("sharkdp", "bat", "x86_64-unknown-linux-musl.tar.gz", "x86_64-pc-windows-msvc.zip", "Better cat", "bat"),
+	"bottom":     newCatalogTool("ClementTsang", "bottom", "bottom_x86_64-unknown-linux-musl.tar.gz", "bottom_x86_64-pc-windows-msvc.zip", "System monitor", "btm"),
+	"delta":      newCatalogTool("dandavison", "delta", "x86_64-unknown-linux-musl.tar.gz", "x86_64-pc-windows-msvc.zip", "Diff tool", "delta"),
+	"direnv":     newCatalogTool("direnv", "direnv", "linux-amd64", "windows-amd64.exe", "Per-directory environment variables", "direnv"),
+	"dive":       newCatalogTool("wagoodman", "dive", "linux_amd64.tar.gz", "windows_amd64.zip", "Explore the layers of container images", "dive"),
+	"dust":       newCatalogTool("bootandy", "dust", "x86_64-unknown-linux-musl.tar.gz", "x86_64-pc-windows-msvc.zip", "Disk usage tool", "dust"),
+	"eza":        newCatalogTool("eza-community", "eza", "x86_64-unknown-linux-musl.tar.gz", "x86_64-pc-windows-gnu.zip", "Better ls", "eza"),
+	"fd":         newCatalogTool("sharkdp", "fd", "x86_64-unknown-linux-musl.tar.gz", "x86_64-pc-windows-msvc.zip", "Better find", "fd"),
+	"fnm":        newCatalogTool("Schniz", "fnm", "fnm-linux.zip", "fnm-windows.zip", "Node.js version manager", "fnm"),
+	"fzf":        newCatalogTool("junegunn", "fzf", "linux_amd64.tar.gz", "windows_amd64.zip", "Fuzzy finder", "fzf"),
+	"gh":         newCatalogTool("cli", "cli", "linux_amd64.tar.gz", "windows_amd64.zip", "GitHub command-line client", "gh"),
+	"glow":       newCatalogTool("charmbracelet", "glow", "Linux_x86_64.tar.gz", "Windows_x86_64.zip", "Markdown renderer for the terminal", "glow"),
+	"hexyl":      newCatalogTool("sharkdp", "hexyl", "x86_64-unknown-linux-musl.tar.gz", "x86_64-pc-windows-msvc.zip", "Hex-viewer", "hexyl"),
+	"hyperfine":  newCatalogTool("sharkdp", "hyperfine", "x86_64-unknown-linux-musl.tar.gz", "x86_64-pc-windows-msvc.zip", "Benchmark tool", "hyperfine"),
+	"jq":         newCatalogTool("jqlang", "jq", "jq-linux-amd64", "jq-windows-amd64.exe", "JSON processor", "jq"),
+	"just":       newCatalogTool("casey", "just", "x86_64-unknown-linux-musl.tar.gz", "x86_64-pc-windows-msvc.zip", "Command runner", "just"),
+	"k9s":        newCatalogTool("derailed", "k9s", "k9s_Linux_amd64.tar.gz", "k9s_Windows_amd64.zip", "Terminal UI for Kubernetes", "k9s"),
+	"kind":       newCatalogTool("kubernetes-sigs", "kind", "kind-linux-amd64", "kind-windows-amd64", "Local Kubernetes clusters in containers", "kind"),
+	"lazydocker": newCatalogTool("jesseduffield", "lazydocker", "Linux_x86_64.tar.gz", "Windows_x86_64.zip", "Terminal UI for Docker", "lazydocker"),
+	"lazygit":    newCatalogTool("jesseduffield", "lazygit", "Linux_x86_64.tar.gz", "Windows_x86_64.zip", "Terminal UI for git", "lazygit"),
+	"micro":      newCatalogTool("zyedidia", "micro", "linux64.tar.gz", "win64.zip", "Command-line editor", "micro"),
+	"ripgrep":    newCatalogTool("burntsushi", "ripgrep", "x86_64-unknown-linux-musl.tar.gz", "x86_64-pc-windows-msvc.zip", "Better grep", "rg"),
+	"sd":         newCatalogTool("chmln", "sd", "x86_64-unknown-linux-musl.tar.gz", "x86_64-pc-windows-msvc.zip", "Better sed", "sd"),
+	"shellcheck": newCatalogTool("koalaman", "shellcheck", "linux.x86_64.tar.xz", ".zip", "Linter for shell scripts", "shellcheck"),
+	"starship":   newCatalogTool("starship", "starship", "x86_64-unknown-linux-musl.tar.gz", "x86_64-pc-windows-msvc.zip", "Cross-shell custom prompt", "starship"),
+	"tokei":      newCatalogTool("XAMPPRocky", "tokei", "x86_64-unknown-linux-musl.tar.gz", "x86_64-pc-windows-msvc.exe", "Code line counting tool", "tokei"),
+	"uv":         newCatalogTool("astral-sh", "uv", "uv-x86_64-unknown-linux-musl.tar.gz", "uv-x86_64-pc-windows-msvc.zip", "Python package manager", "uv", "uvx"),
+	"watchexec":  newCatalogTool("watchexec", "watchexec", "x86_64-unknown-linux-musl.tar.xz", "x86_64-pc-windows-msvc.zip", "Runs commands when files change", "watchexec"),
+	"xh":         newCatalogTool("ducaale", "xh", "x86_64-unknown-linux-musl.tar.gz", "x86_64-pc-windows-msvc.zip", "Friendly HTTP client", "xh"),
+	"yq":         newCatalogTool("mikefarah", "yq", "yq_linux_amd64", "yq_windows_amd64.exe", "YAML, JSON and XML processor", "yq"),
+	"zoxide":     newCatalogTool("ajeetdsouza", "zoxide", "x86_64-unknown-linux-musl.tar.gz", "x86_64-pc-windows-msvc.zip", "Smarter cd", "zoxide"),
+	"tealdeer": {
+		Owner:        "dbrgn",
+		Repository:   "tealdeer",
+		LinuxAsset:   "tealdeer-linux-x86_64-musl",
+		WindowsAsset: "windows-x86_64-msvc.exe",
+		Description:  "Command-line cheatsheets",
+		Binaries:     []Binary{{Name: "tealdeer", RenameTo: "tldr"}},
+	},
+	// Not released on GitHub. Linux only, because the Windows downloads differ in
+	// more than the platform in their URL.
+	"kubectl": {
+		UrlTemplate: "https://dl.k8s.io/release/{tag}/bin/{os}/{arch}/kubectl",
+		VersionUrl:  "https://dl.k8s.io/release/stable.txt",
+		Description: "Kubernetes command-line client",
+		Binaries:    []Binary{{Name: "kubectl"}},
+	},
+	"helm": {
+		UrlTemplate: "https://get.helm.sh/helm-{tag}-{os}-{arch}.tar.gz",
+		VersionUrl:  "https://get.helm.sh/helm-latest-version",
+		Description: "Package manager for Kubernetes",
+		Binaries:    []Binary{{Name: "helm"}},
+	},
+}
+
+func getCatalogFilePath() (string, error) {
+	cacheFilePath, err := getCacheFilePath()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(filepath.Dir(cacheFilePath), "catalog.json"), nil
+}
+
+// Returns the built-in tools, updated with the tools downloaded by 'catalog update'
+func getCatalog() map[string]Tool {
+	result := maps.Clone(knownTools)
+
+	filePath, err := getCatalogFilePath()
+	if err != nil {
+		return result
+	}
+
+	content, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return result
+	}
+
+	var catalog Catalog
+	if err == nil {
+		err = json.Unmarshal(content, &catalog)
+	}
+	if err != nil {
+		logWarning("Could not read the downloaded catalog, run 'tooli catalog update' again: %v.", err)
+		return result
+	}
+
+	// Catalogs downloaded by older versions were not checked yet
+	removeUntrustedCatalogTools(&catalog)
+	maps.Copy(result, catalog.Tools)
+
+	return result
+}
+
+// Leaves out the tools of a downloaded catalog that use entries which need
+// trust, e.g. 'build_command' or a 'token', since 'add' copies them into the
+// configuration and anyone who can change the catalog would otherwise run
+// commands on the next install
+func removeUntrustedCatalogTools(catalog *Catalog) {
+	for _, name := range slices.Sorted(maps.Keys(catalog.Tools)) {
+		tool := catalog.Tools[name]
+		if entries := getPrivilegedToolEntries(&tool); len(entries) > 0 {
+			logWarning("Leaving out tool '%s' of the catalog, it uses %s.", name, strings.Join(entries, ", "))
+			delete(catalog.Tools, name)
+		}
+	}
+}
+
+// Downloads the community-maintained catalog into the cache directory. Tools
+// with problems are left out, so that they cannot be added by accident.
+func updateCatalog(configLocation *string, downloadTimeout int) error {
+	config, err := getConfig(*configLocation)
+	if err != nil {
+		return err
+	}
+
+	downloader, err := newDownloader(downloadTimeout, &config)
+	if err != nil {
+		return err
+	}
+
+	catalogUrl := config.CatalogUrl
+	if catalogUrl == "" {
+		catalogUrl = defaultCatalogUrl
+	}
+	if strings.HasPrefix(catalogUrl, "http://") {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("The catalog '%s' is fetched without encryption, use 'https://' instead.", catalogUrl)
+	}
+
+	content, err := downloader.download(catalogUrl, rtText, "")
+	if err != nil {
+		return err
+	}
+
+	var catalog Catalog
+	err = json.Unmarshal(content, &catalog)
+	if err != nil {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("The catalog at '%s' is not valid: %w.", catalogUrl, err)
+	}
+
+	removeUntrustedCatalogTools(&catalog)
+
+	for name, tool := range catalog.Tools {
+		if problems := getToolProblems(name, &tool); len(problems) > 0 {
+			prefix := fmt.Sprintf("Tool '%s': ", name)
+			for i := range problems {
+				problems[i] = strings.TrimPrefix(problems[i], prefix)
+			}
+			logWarning("Leaving out tool '%s' of the catalog: %s.", name, strings.Join(problems, ", "))
+			delete(catalog.Tools, name)
+		}
+	}
+
+	filePath, err := getCatalogFilePath()
+	if err != nil {
+		return err
+	}
+
+	directory := filepath.Dir(filePath)
+	err = makeOutputDirectory(&directory)
+	if err != nil {
+		return err
+	}
+
+	bytes, err := json.MarshalIndent(catalog, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(filePath, bytes, 0644)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Downloaded %d tools into the catalog.\n", len(catalog.Tools))
+
+	return nil
+}
+
+// Returns whether the search term is part of the tool's name, repository or description
+func matchesCatalogSearch(name string, tool *Tool, term string) bool {
+	term = strings.ToLower(term)
+
+	for _, text := range []string{name, getToolLink(tool), tool.Description} {
+		if strings.Contains(strings.ToLower(text), term) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Lists the tools of the catalog, optionally only those matching the search term
+func listCatalog(searchTerm string) {
+	nameSize := 4
+	linkSize := 16
+
+	catalog := getCatalog()
+
+	tmp := make([]TableEntry, 0, len(catalog))
+	for name, tool := range catalog {
+		if searchTerm != "" && !matchesCatalogSearch(name, &tool, searchTerm) {
+			continue
+		}
+
+		entry := TableEntry{Name: name, Link: getToolLink(&tool), Description: tool.Description}
+		nameSize = max(nameSize, len(entry.Name))
+		linkSize = max(linkSize, len(entry.Link))
+
+		tmp = append(tmp, entry)
+	}
+
+	if len(tmp) == 0 {
+		fmt.Printf("No tool in the catalog matches '%s'.\n", searchTerm)
+		return
+	}
+
+	sort.Sort(ByName[TableEntry]{tmp})
+
+	fmt.Printf("%-*s    %-*s    %s\n\n", nameSize, "Name", linkSize, "Owner/Repository", "Description")
+	for _, entry := range tmp {
+		fmt.Printf("%-*s    %-*s    %s\n", nameSize, entry.Name, linkSize, entry.Link, entry.Description)
+	}
+}
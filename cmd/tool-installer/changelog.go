@@ -0,0 +1,109 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Returns the releases after the installed version up to and including the
+// target release, newest first. Releases are expected in the order returned
+// by the source, which lists the newest release first.
+func getChangelogReleases(source Source, installed string, target *Release) ([]Release, error) {
+	if installed == "" {
+		return []Release{*target}, nil
+	}
+
+	releases, err := source.ListReleases(maxListedReleases)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Release, 0)
+	started := false
+	for _, release := range releases {
+		if isSameVersion(release.TagName, installed) {
+			break
+		}
+
+		if isSameVersion(release.TagName, target.TagName) {
+			started = true
+		}
+
+		if started && !release.Draft {
+			result = append(result, release)
+		}
+	}
+
+	if len(result) == 0 && !isSameVersion(installed, target.TagName) {
+		return []Release{*target}, nil
+	}
+
+	return result, nil
+}
+
+func printChangelog(name string, releases []Release) {
+	for _, release := range releases {
+		title := fmt.Sprintf("%s %s (%s)", name, release.TagName, formatDate(release.PublishedAt))
+		fmt.Printf("%s\n%s\n\n", title, strings.Repeat("=", len(title)))
+
+		body := strings.TrimSpace(strings.ReplaceAll(release.Body, "\r\n", "\n"))
+		if body == "" {
+			body = "No release notes available."
+		}
+		fmt.Printf("%s\n\n", body)
+	}
+}
+
+func showChangelog(configLocation *string, name string, downloadTimeout int) {
+	config, err := getConfig(*configLocation)
+	if err != nil {
+		printConfigError(err)
+		os.Exit(1)
+	}
+
+	tool, found := config.Tools[name]
+	if !found {
+		logError("Tool '%s' not found in configuration.", name)
+		os.Exit(1)
+	}
+
+	cache, err := getCache()
+	if err != nil {
+		logError("Failed to obtain cache. Message: %v", err)
+		os.Exit(1)
+	}
+
+	downloader, err := newDownloader(downloadTimeout, &config)
+	if err != nil {
+		logError("%v", err)
+		os.Exit(1)
+	}
+
+	source, err := downloader.getSource(&tool)
+	if err != nil {
+		logError("%v", err)
+		os.Exit(1)
+	}
+
+	release, err := getToolRelease(source, &tool)
+	if err != nil {
+		logError("Could not obtain the latest release of tool '%v': %v", name, err)
+		os.Exit(1)
+	}
+
+	releases, err := getChangelogReleases(source, cache.Tools[name], &release)
+	if err != nil {
+		logError("Could not obtain the releases of tool '%v': %v", name, err)
+		os.Exit(1)
+	}
+
+	if len(releases) == 0 {
+		fmt.Printf("Tool '%s' is up to date.\n", name)
+		return
+	}
+
+	printChangelog(name, releases)
+}
@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Names of files that list the SHA-256 digests of all assets of a release
+var checksumFileRegex = regexp.MustCompile(`(?i)^(.*[-_.])?(sha256sums|checksums|sha256checksums)(\.txt)?$`)
+
+// BSD style lines look like 'SHA256 (tool.tar.gz) = <digest>'
+var bsdChecksumRegex = regexp.MustCompile(`^SHA256 \((.+)\) = ([0-9a-fA-F]{64})$`)
+
+var sha256Regex = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// Returns the asset holding the checksum of the given asset, preferring a
+// file for just this asset like 'tool.tar.gz.sha256' over a combined one
+func findChecksumAsset(release *Release, asset *Asset) (Asset, bool) {
+	for _, suffix := range []string{".sha256", ".sha256sum"} {
+		for _, a := range release.Assets {
+			if strings.EqualFold(a.Name, asset.Name+suffix) {
+				return a, true
+			}
+		}
+	}
+
+	for _, a := range release.Assets {
+		if checksumFileRegex.MatchString(a.Name) {
+			return a, true
+		}
+	}
+
+	return Asset{}, false
+}
+
+// Finds the digest of the named asset in a checksum file in the format of
+// sha256sum or BSD. Files for a single asset may contain just the digest.
+func parseChecksums(content string, assetName string) (string, bool) {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+
+		if match := bsdChecksumRegex.FindStringSubmatch(line); match != nil {
+			if path.Base(match[1]) == assetName {
+				return strings.ToLower(match[2]), true
+			}
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 || !sha256Regex.MatchString(fields[0]) {
+			continue
+		}
+
+		if len(fields) == 1 && len(lines) <= 2 {
+			return strings.ToLower(fields[0]), true
+		}
+
+		// Binary mode entries are prefixed with '*', some files contain paths
+		if len(fields) == 2 && path.Base(strings.TrimPrefix(fields[1], "*")) == assetName {
+			return strings.ToLower(fields[0]), true
+		}
+	}
+
+	return "", false
+}
+
+// Compares the digest of the downloaded asset to the one reported by the API
+// or published in the release's checksum file. Depending on the policy, assets
+// without any known digest are accepted silently, with a warning or not at all.
+// Digests that were already verified, e.g. from a lockfile, count as known.
+func verifyReleaseChecksum(source Source, release *Release, asset *Asset, digest string, policy string, alreadyVerified bool) error {
+	if policy == checksumPolicyOff {
+		return nil
+	}
+
+	verified := alreadyVerified
+
+	if expected, found := strings.CutPrefix(asset.Digest, "sha256:"); found {
+		if !strings.EqualFold(expected, digest) {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return fmt.Errorf("The SHA-256 digest of '%s' is %s, but the release lists %s.", asset.Name, digest, expected)
+		}
+		verified = true
+	}
+
+	checksumAsset, found := findChecksumAsset(release, asset)
+	if found {
+		content, err := readAsset(source, &checksumAsset)
+		if err != nil {
+			logWarning("Could not download '%s' to verify '%s': %v", checksumAsset.Name, asset.Name, err)
+		} else if expected, found := parseChecksums(string(content), asset.Name); !found {
+			logWarning("'%s' does not contain a SHA-256 digest for '%s'.", checksumAsset.Name, asset.Name)
+		} else if expected != digest {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return fmt.Errorf("The SHA-256 digest of '%s' is %s, but '%s' lists %s.", asset.Name, digest, checksumAsset.Name, expected)
+		} else {
+			logInfo("Verified '%s' using '%s'.", asset.Name, checksumAsset.Name)
+			verified = true
+		}
+	}
+
+	if verified {
+		return nil
+	}
+
+	if policy == checksumPolicyRequire {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("No digest is available to verify '%s', which the checksum policy requires.", asset.Name)
+	}
+
+	logWarning("No digest is available to verify '%s'.", asset.Name)
+	return nil
+}
@@ -0,0 +1,141 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The assets of one release in the download cache
+type CachedDownload struct {
+	directory string
+	size      int64
+	// When the assets were last downloaded or used
+	usedAt time.Time
+}
+
+// Parses a size like '500M' or '2G', the units are powers of 1024
+func parseSize(size string) (int64, error) {
+	number := strings.TrimSuffix(strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(size)), "B"), "I")
+	unit := int64(1)
+	for i, suffix := range []string{"K", "M", "G", "T"} {
+		if trimmed, found := strings.CutSuffix(number, suffix); found {
+			number = trimmed
+			unit = int64(1) << (10 * (i + 1))
+			break
+		}
+	}
+
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil || value < 0 {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return 0, fmt.Errorf("Invalid size '%s', expected e.g. '500M' or '2G'.", size)
+	}
+
+	return int64(value * float64(unit)), nil
+}
+
+// Returns the releases in the download cache, least recently used first
+func getCachedDownloads(downloads string) []CachedDownload {
+	var result []CachedDownload
+
+	sources, _ := os.ReadDir(downloads)
+	for _, source := range sources {
+		releases, _ := os.ReadDir(filepath.Join(downloads, source.Name()))
+		for _, release := range releases {
+			entry := CachedDownload{directory: filepath.Join(downloads, source.Name(), release.Name())}
+
+			filepath.WalkDir(entry.directory, func(path string, file os.DirEntry, err error) error {
+				if err != nil || file.IsDir() {
+					return nil
+				}
+				if info, err := file.Info(); err == nil {
+					entry.size += info.Size()
+					if info.ModTime().After(entry.usedAt) {
+						entry.usedAt = info.ModTime()
+					}
+				}
+				return nil
+			})
+
+			result = append(result, entry)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].usedAt.Before(result[j].usedAt) })
+
+	return result
+}
+
+// Removes assets from the download cache. Without a policy, the assets of
+// versions that are not installed are removed. With maxAge, the assets that
+// were not used for longer are removed, and with maxSize the least recently
+// used ones until the rest fits. A negative maxSize means no limit.
+func cleanCache(configLocation *string, maxSize int64, maxAge time.Duration, all bool) error {
+	config, err := getConfig(*configLocation)
+	if err != nil {
+		return err
+	}
+
+	cache, err := getCache()
+	if err != nil {
+		return err
+	}
+
+	downloads, err := config.getDownloadCache()
+	if err != nil {
+		return err
+	}
+
+	installed := make(map[string]bool)
+	for name, version := range cache.Tools {
+		if tool, found := config.Tools[name]; found {
+			installed[getCachedAssetDirectory(downloads, getSourceKey(&tool), version)] = true
+		}
+	}
+
+	entries := getCachedDownloads(downloads)
+	byPolicy := maxSize >= 0 || maxAge > 0
+
+	var total int64
+	for _, entry := range entries {
+		total += entry.size
+	}
+
+	removed := 0
+	var freed int64
+	for _, entry := range entries {
+		remove := all || (!byPolicy && !installed[entry.directory]) ||
+			(maxAge > 0 && time.Since(entry.usedAt) > maxAge) || (maxSize >= 0 && total > maxSize)
+		if !remove {
+			continue
+		}
+
+		err = os.RemoveAll(entry.directory)
+		if err != nil {
+			return err
+		}
+		os.Remove(filepath.Dir(entry.directory))
+
+		removed++
+		freed += entry.size
+		total -= entry.size
+	}
+
+	if all {
+		cacheFilePath, err := getCacheFilePath()
+		if err == nil {
+			os.RemoveAll(filepath.Join(filepath.Dir(cacheFilePath), "partial"))
+		}
+	}
+
+	fmt.Printf("Removed %d cached release(s), freeing %s. The download cache now holds %s.\n", removed, formatBytes(freed), formatBytes(total))
+
+	return nil
+}
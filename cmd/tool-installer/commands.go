@@ -6,6 +6,9 @@ import (
 	"fmt"
 	"os"
 	"sort"
+	"strings"
+	"sync"
+	"time"
 )
 
 type TableEntry struct {
@@ -57,13 +60,52 @@ func max(a int, b int) int {
 	return a
 }
 
+func getToolLink(tool *Tool) string {
+	if tool.UrlTemplate != "" {
+		return getToolHost(tool)
+	}
+
+	if tool.OciImage != "" {
+		return tool.OciImage
+	}
+
+	if tool.Gist != "" {
+		return "gist:" + tool.Gist
+	}
+
+	if !isGithubHost(tool.Host) {
+		return fmt.Sprintf("%s/%s/%s", tool.Host, tool.Owner, tool.Repository)
+	}
+
+	return fmt.Sprintf("%s/%s", tool.Owner, tool.Repository)
+}
+
+// Returns the release 'install' would install for the tool. Pinned tools
+// need no request because their version is already known.
+func getAvailableRelease(downloader *Downloader, tool *Tool) (Release, error) {
+	if tool.Version != "" {
+		return Release{TagName: tool.Version}, nil
+	}
+
+	if tag, found := downloader.getPrefetchedTag(tool); found {
+		return Release{TagName: tag}, nil
+	}
+
+	source, err := downloader.getSource(tool)
+	if err != nil {
+		return Release{}, err
+	}
+
+	return getToolRelease(source, tool)
+}
+
 func printConfigError(err error) {
-	fmt.Printf("Error: Could not load configuration: %v.\n", err)
+	logError("Could not load configuration: %v.", err)
 	fmt.Println("Check if the configuration file is valid.")
 	fmt.Println("You can generate a new configuration file with 'tooli create-config'.")
 }
 
-func checkToolVersions(configLocation *string, checkAll bool, downloadTimeout int) {
+func checkToolVersions(configLocation *string, checkAll bool, downloadTimeout int, jobs int) {
 	config, err := getConfig(*configLocation)
 	if err != nil {
 		printConfigError(err)
@@ -72,71 +114,96 @@ func checkToolVersions(configLocation *string, checkAll bool, downloadTimeout in
 
 	cache, err := getCache()
 	if err != nil {
-		fmt.Printf("Error: Failed to obtain cache. Message: %v", err)
+		logError("Failed to obtain cache. Message: %v", err)
 		os.Exit(1)
 	}
 
-	downloader := newDownloader(downloadTimeout)
+	downloader, err := newDownloader(downloadTimeout, &config)
+	if err != nil {
+		logError("%v", err)
+		os.Exit(1)
+	}
 
-	var nTools int
-	if checkAll {
-		nTools = len(config.Tools)
-	} else {
-		nTools = len(cache.Tools)
+	var names []string
+	for name, tool := range config.Tools {
+		if _, installed := cache.Tools[name]; (checkAll || installed) && tool.isEnabled() {
+			names = append(names, name)
+		}
 	}
+	sort.Strings(names)
 
-	tmp := make([]VersionTableEntry, nTools)
+	downloader.prefetchLatestTags(&config, names)
 
-	nameSize := 4
-	installedSize := 9
-	availableSize := 9
+	pending := 0
+	for _, name := range names {
+		tool := config.Tools[name]
+		if _, found := downloader.getPrefetchedTag(&tool); !found {
+			pending++
+		}
+	}
 
-	if checkAll {
-		i := 0
-		for k, v := range config.Tools {
-			release, err := downloader.downloadRelease(v.Owner, v.Repository)
-			if err != nil {
-				fmt.Printf("Error obtaining latest release of tool '%v'. Message: %v\n", k, err)
-				continue
-			}
+	var mutex sync.Mutex
+	var tmp []VersionTableEntry
+	var failures []ToolResult
+	stopped := false
 
-			tmp[i] = VersionTableEntry{Name: k, Installed: "", Available: release.TagName}
+	runParallel(names, config.getJobs(jobs), func(name string) {
+		tool := config.Tools[name]
 
-			if current, found := cache.Tools[k]; found {
-				tmp[i].Installed = current
+		// Tools fetched in bulk need no further request
+		if _, found := downloader.getPrefetchedTag(&tool); !found {
+			mutex.Lock()
+			if stopped {
+				mutex.Unlock()
+				return
 			}
-
-			nameSize = max(nameSize, len(k))
-			installedSize = max(installedSize, len(tmp[i].Installed))
-			availableSize = max(availableSize, len(tmp[i].Available))
-
-			i++
-		}
-	} else {
-		i := 0
-		for name, version := range cache.Tools {
-			tool := config.Tools[name]
-			release, err := downloader.downloadRelease(tool.Owner, tool.Repository)
+			err := downloader.checkBudget(pending)
 			if err != nil {
-				fmt.Printf("Error obtaining latest release of tool '%v'. Message: %v\n", name, err)
-				continue
+				stopped = true
+				mutex.Unlock()
+				logError("%v", err)
+				return
 			}
+			pending--
+			mutex.Unlock()
+		}
 
-			tmp[i] = VersionTableEntry{Name: name, Installed: version, Available: release.TagName}
+		release, err := getAvailableRelease(&downloader, &tool)
 
-			nameSize = max(nameSize, len(name))
-			installedSize = max(installedSize, len(tmp[i].Installed))
-			availableSize = max(availableSize, len(tmp[i].Available))
+		mutex.Lock()
+		defer mutex.Unlock()
 
-			i++
+		if err != nil {
+			logError("Could not obtain the latest release of tool '%v': %v", name, err)
+			failures = append(failures, ToolResult{Tool: name, Action: actionFailed, OldVersion: cache.Tools[name], Error: err.Error()})
+			return
 		}
+
+		tmp = append(tmp, VersionTableEntry{Name: name, Installed: cache.Tools[name], Available: release.TagName})
+	})
+
+	nameSize := 4
+	installedSize := 9
+	availableSize := 9
+	for _, entry := range tmp {
+		nameSize = max(nameSize, len(entry.Name))
+		installedSize = max(installedSize, len(entry.Installed))
+		availableSize = max(availableSize, len(entry.Available))
 	}
 
 	sort.Sort(ByName[VersionTableEntry]{tmp})
 
+	if jsonOutput != nil {
+		writeCheckResults(tmp, failures, &cache)
+		return
+	}
+
 	results := make([]VersionTableEntry, 0)
 	for _, entry := range tmp {
-		if entry.Installed != entry.Available {
+		if !isSameVersion(entry.Installed, entry.Available) {
+			if cache.Held[entry.Name] {
+				entry.Available += " (held)"
+			}
 			results = append(results, entry)
 		}
 	}
@@ -152,7 +219,7 @@ func checkToolVersions(configLocation *string, checkAll bool, downloadTimeout in
 	}
 }
 
-func listTools(configLocation *string, longList bool) {
+func listTools(configLocation *string, longList bool, tag string) {
 	config, err := getConfig(*configLocation)
 	if err != nil {
 		printConfigError(err)
@@ -161,7 +228,7 @@ func listTools(configLocation *string, longList bool) {
 
 	cache, err := getCache()
 	if err != nil {
-		fmt.Printf("Error: Failed to obtain cache. Message: %v", err)
+		logError("Failed to obtain cache. Message: %v", err)
 		os.Exit(1)
 	}
 
@@ -171,26 +238,37 @@ func listTools(configLocation *string, longList bool) {
 	descriptionSize := 11
 	versionSize := 7
 
-	tmp := make([]TableEntry, len(config.Tools))
+	tmp := make([]TableEntry, 0, len(config.Tools))
 
-	i := 0
 	for k, v := range config.Tools {
-		tmp[i] = TableEntry{Name: k, Link: fmt.Sprintf("%s/%s", v.Owner, v.Repository), Description: v.Description, Version: ""}
+		if tag != "" && !v.hasTag(tag) {
+			continue
+		}
+
+		entry := TableEntry{Name: k, Link: getToolLink(&v), Description: v.Description, Version: ""}
 
 		if version, found := cache.Tools[k]; found {
-			tmp[i].Version = version
+			entry.Version = version
+		}
+		if !v.isEnabled() {
+			entry.Version = strings.TrimSpace(entry.Version + " (disabled)")
 		}
 
 		nameSize = max(nameSize, len(k))
-		linkSize = max(linkSize, len(tmp[i].Link))
+		linkSize = max(linkSize, len(entry.Link))
 		descriptionSize = max(descriptionSize, len(v.Description))
-		versionSize = max(versionSize, len(tmp[i].Version))
+		versionSize = max(versionSize, len(entry.Version))
 
-		i++
+		tmp = append(tmp, entry)
 	}
 
 	sort.Sort(ByName[TableEntry]{tmp})
 
+	if jsonOutput != nil {
+		writeListResults(tmp, &config, &cache)
+		return
+	}
+
 	if longList {
 		fmt.Printf("%-*s    %-*s    %-*s    %-*s\n\n", nameSize, "Name", linkSize, "Owner/Repository", descriptionSize, "Description", versionSize, "Version")
 
@@ -212,11 +290,143 @@ func listTools(configLocation *string, longList bool) {
 	}
 }
 
+// Formats an RFC 3339 timestamp from the API as a date
+func formatDate(timestamp string) string {
+	parsed, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return timestamp
+	}
+
+	return parsed.Format("2006-01-02")
+}
+
+func listVersions(configLocation *string, name string, limit int, downloadTimeout int) {
+	config, err := getConfig(*configLocation)
+	if err != nil {
+		printConfigError(err)
+		os.Exit(1)
+	}
+
+	tool, found := config.Tools[name]
+	if !found {
+		logError("Tool '%s' not found in configuration.", name)
+		os.Exit(1)
+	}
+
+	cache, err := getCache()
+	if err != nil {
+		logError("Failed to obtain cache. Message: %v", err)
+		os.Exit(1)
+	}
+
+	downloader, err := newDownloader(downloadTimeout, &config)
+	if err != nil {
+		logError("%v", err)
+		os.Exit(1)
+	}
+
+	source, err := downloader.getSource(&tool)
+	if err != nil {
+		logError("%v", err)
+		os.Exit(1)
+	}
+
+	releases, err := source.ListReleases(limit)
+	if err != nil {
+		logError("Could not obtain the releases of tool '%v': %v", name, err)
+		os.Exit(1)
+	}
+
+	if len(releases) == 0 {
+		fmt.Println("No releases found.")
+		return
+	}
+
+	tagSize := 3
+	for _, release := range releases {
+		tagSize = max(tagSize, len(release.TagName))
+	}
+
+	fmt.Printf("%-*s    %-10s    %s\n\n", tagSize, "Tag", "Published", "Notes")
+
+	for _, release := range releases {
+		var notes []string
+		if release.Prerelease {
+			notes = append(notes, "prerelease")
+		}
+		if release.Draft {
+			notes = append(notes, "draft")
+		}
+		if isSameVersion(release.TagName, cache.Tools[name]) {
+			notes = append(notes, "installed")
+		}
+		if release.TagName == tool.Version {
+			notes = append(notes, "pinned")
+		}
+
+		fmt.Printf("%-*s    %-10s    %s\n", tagSize, release.TagName, formatDate(release.PublishedAt), strings.Join(notes, ", "))
+	}
+}
+
+// Holds or unholds the given tools, held tools are skipped when installing all tools
+func setHold(configLocation *string, names []string, hold bool) error {
+	config, err := getConfig(*configLocation)
+	if err != nil {
+		return err
+	}
+
+	cache, err := getCache()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if _, found := config.Tools[name]; !found {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return fmt.Errorf("Tool '%s' not found in configuration.", name)
+		}
+
+		if hold {
+			cache.Held[name] = true
+			fmt.Printf("Holding tool '%s'.\n", name)
+		} else {
+			delete(cache.Held, name)
+			fmt.Printf("No longer holding tool '%s'.\n", name)
+		}
+	}
+
+	return cache.writeCache()
+}
+
 func makeOutputDirectory(path *string) error {
 	return os.MkdirAll(*path, 0755)
 }
 
-func installTools(configLocation *string, installOnly *string, downloadTimeout int) {
+// Splits a tool given on the command line as 'name@version' into its parts
+func parseToolSpec(spec string) (string, string) {
+	name, version, _ := strings.Cut(spec, "@")
+	return name, version
+}
+
+// Options of 'install' that apply to all tools, zero values are the defaults
+type InstallToolsOptions struct {
+	// Tools as 'name' or 'name@version', all configured tools if empty
+	ToolSpecs       []string
+	DownloadTimeout int
+	// Installs exactly the versions from the lockfile
+	Locked       bool
+	LockfilePath string
+	// Number of tools installed at the same time, 0 for the configured number
+	Jobs int
+	// Bytes per second for downloading assets, 0 for no limit
+	LimitRate int64
+	// Results of earlier steps, e.g. removed tools, reported with the installs
+	Results []ToolResult
+	// Passed on to every tool, the version comes from the tool spec
+	InstallOptions
+}
+
+func installTools(configLocation *string, options InstallToolsOptions) {
 	config, err := getConfig(*configLocation)
 	if err != nil {
 		printConfigError(err)
@@ -225,34 +435,192 @@ func installTools(configLocation *string, installOnly *string, downloadTimeout i
 
 	err = makeOutputDirectory(&config.InstallationDirectory)
 	if err != nil {
-		fmt.Printf("Error: Could not create output directory %v.\n", config.InstallationDirectory)
+		logError("Could not create output directory %v.", config.InstallationDirectory)
 		os.Exit(1)
 	}
 
 	cache, err := getCache()
 	if err != nil {
-		fmt.Printf("Error: Could not obtain cache directory.\n")
+		logError("Could not obtain cache directory.")
+		os.Exit(1)
+	}
+
+	downloader, err := newDownloader(options.DownloadTimeout, &config)
+	if err != nil {
+		logError("%v", err)
+		os.Exit(1)
+	}
+
+	if toolsDirectory, err := getToolDirectory(""); err == nil {
+		removeReplacedFiles(config.InstallationDirectory, toolsDirectory)
+	}
+
+	jobs := config.getJobs(options.Jobs)
+	downloader.cancelOnInterrupt()
+	downloader.limitBandwidth(options.LimitRate)
+
+	if options.Locked {
+		installLockedTools(&downloader, &options.LockfilePath, options.AllowDowngrade, options.Force, &config, &cache, jobs)
+		return
+	}
+
+	toolSpecs, tagged, err := config.expandToolSpecs(options.ToolSpecs)
+	if err != nil {
+		logError("%v", err)
 		os.Exit(1)
 	}
 
-	downloader := newDownloader(downloadTimeout)
+	restoreOutput := downloader.progress.captureOutput()
+
+	var mutex sync.Mutex
+	results := options.Results
+	failed := false
+	for _, result := range results {
+		failed = failed || result.Action == actionFailed
+	}
+	addResult := func(result ToolResult) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		results = append(results, result)
+		if result.Action == actionFailed {
+			failed = true
+		}
+	}
 
-	if *installOnly != "" {
-		fmt.Printf("Installing tool '%s'.\n", *installOnly)
-		err = downloader.downloadTool(*installOnly, &config, &cache)
+	install := func(name string, spec string, toolOptions InstallOptions) {
+		oldVersion, _ := cache.getVersion(name)
+		if downloader.isInterrupted() {
+			addResult(ToolResult{Tool: name, Action: actionCancelled, OldVersion: oldVersion})
+			return
+		}
+
+		logInfo("Installing tool '%s'.", spec)
+		err := downloader.downloadTool(name, toolOptions, &config, &cache)
+		if err != nil && downloader.isInterrupted() {
+			logInfo("Cancelled installing tool '%s'.", name)
+			addResult(ToolResult{Tool: name, Action: actionCancelled, OldVersion: oldVersion})
+			return
+		}
 		if err != nil {
-			fmt.Println("Error:", err)
-			os.Exit(1)
+			logError("Could not install tool '%s': %v", name, err)
 		}
+		newVersion, _ := cache.getVersion(name)
+		addResult(getInstallResult(name, oldVersion, newVersion, err))
+	}
+
+	if len(toolSpecs) > 0 {
+		var names []string
+		for _, spec := range toolSpecs {
+			if name, _ := parseToolSpec(spec); !tagged[name] || !cache.Held[name] {
+				names = append(names, name)
+			}
+		}
+		downloader.prefetchLatestTags(&config, names)
+
+		runParallel(toolSpecs, jobs, func(spec string) {
+			name, version := parseToolSpec(spec)
+
+			// Tools given by name are installed even if they are held
+			if tagged[name] && cache.Held[name] {
+				logInfo("Skipping tool '%s' because it is held.", name)
+				oldVersion, _ := cache.getVersion(name)
+				addResult(ToolResult{Tool: name, Action: actionSkipped, OldVersion: oldVersion, Held: true})
+				return
+			}
+
+			if tool, found := config.Tools[name]; found && !tool.isEnabled() {
+				logInfo("Skipping tool '%s' because it is disabled.", name)
+				oldVersion, _ := cache.getVersion(name)
+				addResult(ToolResult{Tool: name, Action: actionSkipped, OldVersion: oldVersion})
+				return
+			}
+
+			toolOptions := options.InstallOptions
+			toolOptions.Version = version
+			install(name, spec, toolOptions)
+		})
 	} else {
-		for k := range config.Tools {
-			fmt.Printf("Installing tool '%s'.\n", k)
-			err = downloader.downloadTool(k, &config, &cache)
-			if err != nil {
-				fmt.Println("Error:", err)
+		names := config.getToolNames()
+
+		// Held and disabled tools are skipped before any request is made
+		var active []string
+		for _, name := range names {
+			if tool := config.Tools[name]; !cache.Held[name] && tool.isEnabled() {
+				active = append(active, name)
 			}
 		}
+		downloader.prefetchLatestTags(&config, active)
+
+		// Installing a tool takes one request for the release and one for the
+		// asset, tools that are known to be up to date take none
+		needsRequests := func(name string) bool {
+			tool := config.Tools[name]
+			tag, prefetched := downloader.getPrefetchedTag(&tool)
+			installed, found := cache.getVersion(name)
+			return options.Force || !prefetched || !found || !isSameVersion(installed, tag)
+		}
+
+		pending := 0
+		for _, name := range active {
+			if needsRequests(name) {
+				pending++
+			}
+		}
+
+		// Set once the rate limit does not suffice for the remaining tools
+		var budgetErr error
+		runParallel(names, jobs, func(name string) {
+			oldVersion, _ := cache.getVersion(name)
+
+			if cache.Held[name] {
+				logInfo("Skipping tool '%s' because it is held.", name)
+				addResult(ToolResult{Tool: name, Action: actionSkipped, OldVersion: oldVersion, Held: true})
+				return
+			}
+
+			if tool := config.Tools[name]; !tool.isEnabled() {
+				logInfo("Skipping tool '%s' because it is disabled.", name)
+				addResult(ToolResult{Tool: name, Action: actionSkipped, OldVersion: oldVersion})
+				return
+			}
+
+			if needsRequests(name) {
+				mutex.Lock()
+				if budgetErr == nil {
+					budgetErr = downloader.checkBudget(2 * pending)
+					if budgetErr != nil {
+						logError("%v", budgetErr)
+					}
+				}
+				err := budgetErr
+				pending--
+				mutex.Unlock()
+
+				if err != nil {
+					addResult(ToolResult{Tool: name, Action: actionFailed, OldVersion: oldVersion, Error: err.Error()})
+					return
+				}
+			}
+
+			install(name, name, options.InstallOptions)
+		})
 	}
 
+	restoreOutput()
+
 	cache.writeCache()
+	if jsonOutput != nil {
+		writeToolResults(results)
+	} else {
+		printSummary(results)
+	}
+
+	if downloader.isInterrupted() {
+		os.Exit(interruptedExitCode)
+	}
+
+	if failed {
+		os.Exit(failedToolsExitCode)
+	}
 }
@@ -0,0 +1,319 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// The commands offered by the completion scripts
+var completionCommands = []string{
+	"install", "update", "sync", "check", "create-config", "list", "lock", "rollback", "versions", "changelog",
+	"info", "verify", "which", "status", "prune", "doctor", "trust", "hold", "unhold", "add", "search",
+	"edit", "rename", "migrate-config", "import", "export", "catalog", "config", "cache", "completions", "ui",
+}
+
+// The commands whose arguments are names of configured tools
+var toolNameCommands = []string{
+	"i", "install", "update", "rollback", "versions", "changelog", "info", "verify", "which",
+	"hold", "unhold", "edit", "rename", "export",
+}
+
+var completionShells = []string{"bash", "zsh", "fish", "powershell"}
+
+// The scripts list the tool names by running 'tooli completions --tool-names',
+// so they always match the configuration. They skip the values of options to
+// find the command and pass '--config' on.
+const bashCompletion = `# bash completion for tooli, load it with 'source <(tooli completions bash)'
+_tooli() {
+    local cur="${COMP_WORDS[COMP_CWORD]}"
+    local command="" config="" i
+    for ((i = 1; i < COMP_CWORD; i++)); do
+        case "${COMP_WORDS[i]}" in
+            --config=*|-config=*)
+                config="${COMP_WORDS[i]#*=}"
+                ;;
+            --config|-config)
+                # bash may split '--config=value' into three words
+                ((i++))
+                if [[ "${COMP_WORDS[i]}" == "=" ]]; then
+                    ((i++))
+                fi
+                config="${COMP_WORDS[i]}"
+                ;;
+            {{valueOptionsPipe}})
+                ((i++))
+                if [[ "${COMP_WORDS[i]}" == "=" ]]; then
+                    ((i++))
+                fi
+                ;;
+            -*)
+                ;;
+            *)
+                if [[ -z "$command" ]]; then
+                    command="${COMP_WORDS[i]}"
+                fi
+                ;;
+        esac
+    done
+
+    # The current word is the value of an option
+    if ((i > COMP_CWORD)); then
+        COMPREPLY=($(compgen -f -- "$cur"))
+        return
+    fi
+
+    if [[ -z "$command" ]]; then
+        COMPREPLY=($(compgen -W "{{commands}}" -- "$cur"))
+        return
+    fi
+
+    case "$command" in
+        {{toolCommandsPipe}})
+            if [[ "$cur" != -* ]]; then
+                COMPREPLY=($(compgen -W "$(tooli completions --tool-names ${config:+--config "$config"} 2>/dev/null)" -- "$cur"))
+            fi
+            ;;
+        completions)
+            COMPREPLY=($(compgen -W "{{shells}}" -- "$cur"))
+            ;;
+    esac
+}
+complete -F _tooli tooli
+`
+
+const zshCompletion = `#compdef tooli
+# zsh completion for tooli, load it with 'source <(tooli completions zsh)'
+# after compinit
+_tooli() {
+    local command="" i
+    local -a config
+    for ((i = 2; i < CURRENT; i++)); do
+        case "${words[i]}" in
+            (--config=*|-config=*)
+                config=(--config "${words[i]#*=}")
+                ;;
+            (--config|-config)
+                config=(--config "${words[i+1]}")
+                ((i++))
+                ;;
+            ({{valueOptionsPipe}})
+                ((i++))
+                ;;
+            (-*)
+                ;;
+            (*)
+                if [[ -z "$command" ]]; then
+                    command="${words[i]}"
+                fi
+                ;;
+        esac
+    done
+
+    # The current word is the value of an option
+    if ((i > CURRENT)); then
+        _files
+        return
+    fi
+
+    if [[ -z "$command" ]]; then
+        compadd -- {{commands}}
+        return
+    fi
+
+    case "$command" in
+        ({{toolCommandsPipe}})
+            compadd -- ${(f)"$(tooli completions --tool-names $config 2>/dev/null)"}
+            ;;
+        (completions)
+            compadd -- {{shells}}
+            ;;
+    esac
+}
+
+if [[ "$funcstack[1]" == "_tooli" ]]; then
+    _tooli "$@"
+else
+    compdef _tooli tooli
+fi
+`
+
+const fishCompletion = `# fish completion for tooli, load it with 'tooli completions fish | source'
+function __tooli_command
+    set -l words (commandline -opc)
+    set -e words[1]
+    set -l skip 0
+    for word in $words
+        if test $skip -eq 1
+            set skip 0
+        else if contains -- $word {{valueOptions}}
+            set skip 1
+        else if not string match -q -- '-*' $word
+            echo $word
+            return
+        end
+    end
+end
+
+function __tooli_needs_command
+    set -l command (__tooli_command)
+    test -z "$command"
+end
+
+function __tooli_using_command
+    set -l command (__tooli_command)
+    contains -- "$command" $argv
+end
+
+function __tooli_tool_names
+    set -l words (commandline -opc)
+    set -l config
+    for i in (seq 2 (count $words))
+        switch $words[$i]
+            case --config -config
+                if test $i -lt (count $words)
+                    set config --config $words[(math $i + 1)]
+                end
+            case '--config=*' '-config=*'
+                set config --config (string split -m 1 = -- $words[$i])[2]
+        end
+    end
+    tooli completions --tool-names $config 2>/dev/null
+end
+
+complete -c tooli -f
+complete -c tooli -n __tooli_needs_command -a '{{commands}}'
+complete -c tooli -n '__tooli_using_command {{toolCommands}}' -a '(__tooli_tool_names)'
+complete -c tooli -n '__tooli_using_command completions' -a '{{shells}}'
+`
+
+const powershellCompletion = `# PowerShell completion for tooli, load it with
+# 'tooli completions powershell | Out-String | Invoke-Expression'
+Register-ArgumentCompleter -Native -CommandName tooli -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+
+    $elements = @($commandAst.CommandElements | Select-Object -Skip 1 | ForEach-Object { $_.ToString() })
+    if ($wordToComplete -ne '' -and $elements.Count -gt 0) {
+        $elements = @($elements | Select-Object -SkipLast 1)
+    }
+
+    $valueOptions = @({{valueOptionsQuoted}})
+    $words = @()
+    $config = @()
+    for ($i = 0; $i -lt $elements.Count; $i++) {
+        $word = $elements[$i]
+        if ($word -like '--config=*' -or $word -like '-config=*') {
+            $config = @('--config', $word.Substring($word.IndexOf('=') + 1))
+        } elseif ($valueOptions -contains $word) {
+            if ($word -in @('--config', '-config') -and $i + 1 -lt $elements.Count) {
+                $config = @('--config', $elements[$i + 1])
+            }
+            $i++
+        } elseif ($word -notlike '-*') {
+            $words += $word
+        }
+    }
+
+    if ($i -gt $elements.Count) {
+        # The current word is the value of an option
+        return
+    } elseif ($words.Count -eq 0) {
+        $candidates = @({{commandsQuoted}})
+    } elseif (@({{toolCommandsQuoted}}) -contains $words[0]) {
+        $candidates = @(tooli completions --tool-names @config 2>$null)
+    } elseif ($words[0] -eq 'completions') {
+        $candidates = @({{shellsQuoted}})
+    } else {
+        return
+    }
+
+    $candidates | Where-Object { $_ -like "$wordToComplete*" } | ForEach-Object {
+        [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
+    }
+}
+`
+
+// Returns the options that take a value, with one and two dashes, since the
+// flag package accepts both
+func getValueOptions() []string {
+	names := map[string]bool{"--profile": true}
+	for _, flagSet := range commandFlagSets {
+		flagSet.VisitAll(func(option *flag.Flag) {
+			if value, ok := option.Value.(interface{ IsBoolFlag() bool }); ok && value.IsBoolFlag() {
+				return
+			}
+			names["-"+option.Name] = true
+			names["--"+option.Name] = true
+		})
+	}
+
+	result := make([]string, 0, len(names))
+	for name := range names {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+func quoteWords(words []string) string {
+	quoted := make([]string, len(words))
+	for i, word := range words {
+		quoted[i] = "'" + word + "'"
+	}
+
+	return strings.Join(quoted, ", ")
+}
+
+// Returns the completion script for the shell
+func getCompletionScript(shell string) (string, error) {
+	var script string
+	switch shell {
+	case "bash":
+		script = bashCompletion
+	case "zsh":
+		script = zshCompletion
+	case "fish":
+		script = fishCompletion
+	case "powershell", "pwsh":
+		script = powershellCompletion
+	default:
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return "", fmt.Errorf("Unknown shell '%s', expected one of %s.", shell, strings.Join(completionShells, ", "))
+	}
+
+	valueOptions := getValueOptions()
+	replacer := strings.NewReplacer(
+		"{{valueOptions}}", strings.Join(valueOptions, " "),
+		"{{valueOptionsPipe}}", strings.Join(valueOptions, "|"),
+		"{{valueOptionsQuoted}}", quoteWords(valueOptions),
+		"{{commands}}", strings.Join(completionCommands, " "),
+		"{{commandsQuoted}}", quoteWords(completionCommands),
+		"{{toolCommands}}", strings.Join(toolNameCommands, " "),
+		"{{toolCommandsPipe}}", strings.Join(toolNameCommands, "|"),
+		"{{toolCommandsQuoted}}", quoteWords(toolNameCommands),
+		"{{shells}}", strings.Join(completionShells, " "),
+		"{{shellsQuoted}}", quoteWords(completionShells),
+	)
+
+	return replacer.Replace(script), nil
+}
+
+// Prints the names of the configured tools for the completion scripts,
+// nothing if the configuration cannot be loaded
+func printToolNames(configLocation *string) {
+	// Warnings about the configuration would end up as completions
+	logLevel = levelQuiet
+
+	config, err := getConfig(*configLocation)
+	if err != nil {
+		return
+	}
+
+	for _, name := range config.getToolNames() {
+		fmt.Println(name)
+	}
+}
@@ -3,11 +3,19 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
 )
 
 type Binary struct {
@@ -16,34 +24,628 @@ type Binary struct {
 }
 
 type Tool struct {
-	Binaries     []Binary `json:"binaries"`
-	Owner        string   `json:"owner"`
-	Repository   string   `json:"repository"`
-	LinuxAsset   string   `json:"linux_asset"`
-	WindowsAsset string   `json:"windows_asset"`
-	AssetPrefix  string   `json:"asset_prefix,omitempty"`
-	Description  string   `json:"description"`
+	Binaries          []Binary          `json:"binaries"`
+	Host              string            `json:"host,omitempty"`
+	Owner             string            `json:"owner"`
+	Repository        string            `json:"repository"`
+	LinuxAsset        string            `json:"linux_asset"`
+	WindowsAsset      string            `json:"windows_asset"`
+	AssetPrefix       string            `json:"asset_prefix,omitempty"`
+	UrlTemplate       string            `json:"url_template,omitempty"`
+	VersionUrl        string            `json:"version_url,omitempty"`
+	VersionRegex      string            `json:"version_regex,omitempty"`
+	OciImage          string            `json:"oci_image,omitempty"`
+	OciTag            string            `json:"oci_tag,omitempty"`
+	Gist              string            `json:"gist,omitempty"`
+	GistRevision      string            `json:"gist_revision,omitempty"`
+	BuildCommand      string            `json:"build_command,omitempty"`
+	Token             *TokenSource      `json:"token,omitempty"`
+	Headers           map[string]string `json:"headers,omitempty"`
+	Description       string            `json:"description"`
+	Tags              []string          `json:"tags,omitempty"`
+	Enabled           *bool             `json:"enabled,omitempty"`
+	Version           string            `json:"version,omitempty"`
+	VersionConstraint string            `json:"version_constraint,omitempty"`
+	AllowPrerelease   *bool             `json:"allow_prerelease,omitempty"`
+	PreferStatic      *bool             `json:"prefer_static,omitempty"`
+	Timeout           int               `json:"timeout,omitempty"`
+	ChecksumPolicy    string            `json:"checksum_policy,omitempty"`
+	IgnoreVersions    []string          `json:"ignore_versions,omitempty"`
+	MinisignPubkey    string            `json:"minisign_pubkey,omitempty"`
+	GpgKey            string            `json:"gpg_key,omitempty"`
+	CosignPubkey      string            `json:"cosign_pubkey,omitempty"`
+	CosignIdentity    string            `json:"cosign_identity,omitempty"`
+	CosignIssuer      string            `json:"cosign_issuer,omitempty"`
+	Attestation       bool              `json:"attestation,omitempty"`
+	Sha256            map[string]string `json:"sha256,omitempty"`
+	Completions       map[string]string `json:"completions,omitempty"`
+	ManPages          []string          `json:"man_pages,omitempty"`
+	InstallMode       string            `json:"install_mode,omitempty"`
+	RunInstaller      bool              `json:"run_installer,omitempty"`
+	InstallerArgs     []string          `json:"installer_args,omitempty"`
+}
+
+// The entries of a tool that can be set once for all tools in 'defaults'.
+// Entries that identify a tool or its release, e.g. 'repository' or
+// 'version', are not inherited.
+type ToolDefaults struct {
+	Host            string            `json:"host,omitempty"`
+	LinuxAsset      string            `json:"linux_asset,omitempty"`
+	WindowsAsset    string            `json:"windows_asset,omitempty"`
+	AssetPrefix     string            `json:"asset_prefix,omitempty"`
+	Headers         map[string]string `json:"headers,omitempty"`
+	Tags            []string          `json:"tags,omitempty"`
+	AllowPrerelease *bool             `json:"allow_prerelease,omitempty"`
+	PreferStatic    *bool             `json:"prefer_static,omitempty"`
+	Timeout         int               `json:"timeout,omitempty"`
+	ChecksumPolicy  string            `json:"checksum_policy,omitempty"`
+	InstallMode     string            `json:"install_mode,omitempty"`
 }
 
 type Configuration struct {
-	InstallationDirectory string          `json:"install_dir"`
-	Tools                 map[string]Tool `json:"tools"`
+	SchemaVersion         int                          `json:"schema_version,omitempty"`
+	InstallationDirectory string                       `json:"install_dir"`
+	ExcludeAssets         []string                     `json:"exclude_assets,omitempty"`
+	Tokens                map[string]TokenSource       `json:"tokens,omitempty"`
+	CredentialFallback    bool                         `json:"credential_fallback,omitempty"`
+	Mirrors               map[string]string            `json:"mirrors,omitempty"`
+	Headers               map[string]map[string]string `json:"headers,omitempty"`
+	Proxy                 string                       `json:"proxy,omitempty"`
+	CaCertificate         string                       `json:"ca_certificate,omitempty"`
+	InsecureSkipVerify    bool                         `json:"insecure_skip_verify,omitempty"`
+	KeepVersions          *int                         `json:"keep_versions,omitempty"`
+	ChecksumPolicy        string                       `json:"checksum_policy,omitempty"`
+	RequireTrust          bool                         `json:"require_trust,omitempty"`
+	CompletionDirectories map[string]string            `json:"completion_dirs,omitempty"`
+	ManDirectory          string                       `json:"man_dir,omitempty"`
+	DownloadDirectory     string                       `json:"download_dir,omitempty"`
+	DownloadCache         string                       `json:"download_cache,omitempty"`
+	CatalogUrl            string                       `json:"catalog_url,omitempty"`
+	Jobs                  int                          `json:"jobs,omitempty"`
+	Retry                 *RetryConfig                 `json:"retry,omitempty"`
+	Include               []string                     `json:"include,omitempty"`
+	Profiles              map[string]Profile           `json:"profiles,omitempty"`
+	Defaults              *ToolDefaults                `json:"defaults,omitempty"`
+	Tools                 map[string]Tool              `json:"tools"`
+
+	excludeRegexes []*regexp.Regexp
 }
 
-func getConfig(path string) (Configuration, error) {
+const defaultKeepVersions = 3
+
+// Returns how many installed versions of each tool are kept for rollbacks
+func (config *Configuration) getKeepVersions() int {
+	if config.KeepVersions == nil {
+		return defaultKeepVersions
+	}
+
+	return *config.KeepVersions
+}
+
+// Returns the digest pinned in the configuration for the version of the tool
+// on the current platform, which is given as '<version>/<os>' or '<version>'
+func (tool *Tool) getPinnedSha256(version string) string {
+	if digest, found := tool.Sha256[version+"/"+runtime.GOOS]; found {
+		return digest
+	}
+
+	return tool.Sha256[version]
+}
+
+const (
+	// Extracts the whole archive and links the binaries, see extractDirectory
+	installModeDirectory = "directory"
+	// Keeps the asset as an installer, see storeInstaller
+	installModeInstaller = "installer"
+)
+
+// How to handle assets without a published digest
+const (
+	checksumPolicyOff     = "off"
+	checksumPolicyWarn    = "warn"
+	checksumPolicyRequire = "require"
+)
+
+func (config *Configuration) getChecksumPolicy() string {
+	if config.ChecksumPolicy == "" {
+		return checksumPolicyWarn
+	}
+
+	return config.ChecksumPolicy
+}
+
+// The tool's own checksum_policy replaces the one of the configuration
+func (config *Configuration) getToolChecksumPolicy(tool *Tool) string {
+	if tool.ChecksumPolicy != "" {
+		return tool.ChecksumPolicy
+	}
+
+	return config.getChecksumPolicy()
+}
+
+func isValidChecksumPolicy(policy string) bool {
+	switch policy {
+	case checksumPolicyOff, checksumPolicyWarn, checksumPolicyRequire:
+		return true
+	default:
+		return false
+	}
+}
+
+// Whether the signature of the tool's assets is verified
+func (tool *Tool) isSignatureVerified() bool {
+	return tool.MinisignPubkey != "" || tool.GpgKey != "" || tool.CosignPubkey != "" || tool.CosignIdentity != "" || tool.Attestation
+}
+
+func (tool *Tool) allowsPrerelease() bool {
+	return tool.AllowPrerelease != nil && *tool.AllowPrerelease
+}
+
+// Whether statically linked assets are preferred if several assets match
+func (tool *Tool) prefersStatic() bool {
+	return tool.PreferStatic != nil && *tool.PreferStatic
+}
+
+// Assets matching any of these are never considered for installation, unless
+// the configuration provides its own list
+var defaultExcludeAssets = []string{
+	`(?i)\.(sig|asc|minisig|pem|sbom|deb|rpm|apk)$`,
+	`(?i)\.(sha256|sha512)(sum)?$`,
+	`(?i)checksums?`,
+	`(?i)[-_.](src|source|vendored)(\.|$)`,
+}
+
+func compileExcludeAssets(patterns []string) ([]*regexp.Regexp, error) {
+	result := make([]*regexp.Regexp, 0, len(patterns))
+
+	for _, pattern := range patterns {
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return nil, fmt.Errorf("Invalid regular expression '%s' in 'exclude_assets': %v", pattern, err)
+		}
+		result = append(result, regex)
+	}
+
+	return result, nil
+}
+
+func (config *Configuration) isExcludedAsset(assetName string) bool {
+	for _, regex := range config.excludeRegexes {
+		if regex.MatchString(assetName) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Whether the configuration file is TOML instead of JSON, based on its extension
+func isTomlFile(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".toml")
+}
+
+// Decodes a configuration file of either format into a generic document
+func decodeRawConfiguration(path string, content []byte) (map[string]any, error) {
+	var raw map[string]any
+	var err error
+	if isTomlFile(path) {
+		_, err = toml.Decode(string(content), &raw)
+	} else {
+		err = json.Unmarshal(stripJsonComments(content), &raw)
+	}
+	if raw == nil && err == nil {
+		raw = make(map[string]any)
+	}
+
+	return raw, err
+}
+
+// TOML is converted to JSON before decoding, so that both formats use the
+// same field names. JSON may contain comments and trailing commas. Files of
+// an older schema are upgraded in memory.
+func parseConfiguration(path string, content []byte, config *Configuration) error {
+	raw, err := decodeRawConfiguration(path, content)
+	if err != nil {
+		return err
+	}
+
+	migrated, err := migrateRawConfiguration(raw)
+	if err != nil {
+		return err
+	}
+	if migrated {
+		logWarning("The configuration '%s' uses an old format, run 'tooli migrate-config' to upgrade it.", path)
+	}
+
+	content, err = json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(content, config)
+}
+
+// Converts a JSON configuration to TOML
+func convertToToml(content []byte) ([]byte, error) {
+	var raw map[string]any
+	err := json.Unmarshal(content, &raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var result bytes.Buffer
+	encoder := toml.NewEncoder(&result)
+	encoder.Indent = ""
+	err = encoder.Encode(raw)
+
+	return result.Bytes(), err
+}
+
+// Returns the files listed in 'include', which may be glob patterns relative to
+// the configuration or remote locations, followed by the JSON and TOML files in
+// the config.d directory of the local configuration. The remote locations are
+// returned by the path of their cached file.
+func (config *Configuration) getIncludedFiles(path string, localDirectory string) ([]string, map[string]string, error) {
+	directory := filepath.Dir(path)
+
+	var result []string
+	var patterns []string
+	seen := make(map[string]bool)
+	remote := make(map[string]string)
+	for _, pattern := range config.Include {
+		if isRemoteLocation(pattern) {
+			file, err := resolveRemoteLocation(pattern)
+			if err != nil {
+				return nil, nil, err
+			}
+			if !seen[file] {
+				seen[file] = true
+				result = append(result, file)
+				remote[file] = pattern
+			}
+			continue
+		}
+
+		pattern = expandPath(pattern)
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(directory, pattern)
+		}
+		patterns = append(patterns, pattern)
+	}
+	includeCount := len(patterns)
+	patterns = append(patterns, filepath.Join(localDirectory, "config.d", "*.json"), filepath.Join(localDirectory, "config.d", "*.toml"))
+
+	for i, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return nil, nil, fmt.Errorf("Invalid pattern '%s' in 'include': %v", pattern, err)
+		}
+
+		// Included files that are not patterns have to exist
+		if len(matches) == 0 && i < includeCount && !strings.ContainsAny(pattern, "*?[") {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return nil, nil, fmt.Errorf("The included file '%s' does not exist", pattern)
+		}
+
+		for _, match := range matches {
+			if !seen[match] {
+				seen[match] = true
+				result = append(result, match)
+			}
+		}
+	}
+
+	return result, remote, nil
+}
+
+// Merges the tools of the included configuration files into the configuration,
+// all other entries of included files are ignored
+func (config *Configuration) mergeIncludes(path string, localDirectory string) error {
+	files, remote, err := config.getIncludedFiles(path, localDirectory)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		var fragment Configuration
+		err = parseConfiguration(file, content, &fragment)
+		if err != nil {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return fmt.Errorf("Invalid included file '%s': %v", file, err)
+		}
+
+		if location, found := remote[file]; found {
+			err = checkRemoteConfiguration(location, &fragment)
+			if err != nil {
+				return err
+			}
+		}
+
+		if config.Tools == nil {
+			config.Tools = make(map[string]Tool)
+		}
+		for name, tool := range fragment.Tools {
+			if _, found := config.Tools[name]; found {
+				//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+				return fmt.Errorf("The tool '%s' in the included file '%s' is already defined", name, file)
+			}
+			config.Tools[name] = tool
+		}
+	}
+
+	return nil
+}
+
+// Reads the configuration and its included files without validating them
+func loadConfiguration(path string) (Configuration, error) {
 	var config Configuration
 
-	bytes, err := os.ReadFile(replaceTildePath(path))
+	filePath := replaceTildePath(path)
+	localDirectory := filepath.Dir(filePath)
+	if isRemoteLocation(path) {
+		var err error
+		filePath, err = resolveRemoteLocation(path)
+		if err != nil {
+			return config, err
+		}
+
+		// Local additions to a remote configuration are kept next to the
+		// default configuration
+		defaultPath, err := getConfigFilePath()
+		if err != nil {
+			return config, err
+		}
+		localDirectory = filepath.Dir(defaultPath)
+	}
+
+	bytes, err := os.ReadFile(filePath)
 	if err != nil {
 		return config, err
 	}
 
-	err = json.Unmarshal(bytes, &config)
+	err = parseConfiguration(filePath, bytes, &config)
 	if err != nil {
 		return config, err
 	}
 
-	config.InstallationDirectory = replaceTildePath(config.InstallationDirectory)
+	if isRemoteLocation(path) {
+		err = checkRemoteConfiguration(path, &config)
+		if err != nil {
+			return config, err
+		}
+	}
+
+	err = config.mergeIncludes(filePath, localDirectory)
+	if err != nil {
+		return config, err
+	}
+
+	config.applyDefaults()
+
+	if config.InstallationDirectory == "" {
+		config.InstallationDirectory = getDefaultInstallationDirectory()
+	}
+	config.InstallationDirectory = expandPath(config.InstallationDirectory)
+
+	return config, nil
+}
+
+// Sets every entry of 'defaults' that a tool does not set itself. Each tool
+// gets its own copy, so that changing one tool does not change the others.
+func (config *Configuration) applyDefaults() {
+	defaults := config.Defaults
+	if defaults == nil {
+		return
+	}
+
+	for name, tool := range config.Tools {
+		if tool.Host == "" {
+			tool.Host = defaults.Host
+		}
+		if tool.LinuxAsset == "" {
+			tool.LinuxAsset = defaults.LinuxAsset
+		}
+		if tool.WindowsAsset == "" {
+			tool.WindowsAsset = defaults.WindowsAsset
+		}
+		if tool.AssetPrefix == "" {
+			tool.AssetPrefix = defaults.AssetPrefix
+		}
+		if tool.Headers == nil {
+			tool.Headers = maps.Clone(defaults.Headers)
+		}
+		if tool.Tags == nil {
+			tool.Tags = slices.Clone(defaults.Tags)
+		}
+		if tool.AllowPrerelease == nil {
+			tool.AllowPrerelease = defaults.AllowPrerelease
+		}
+		if tool.PreferStatic == nil {
+			tool.PreferStatic = defaults.PreferStatic
+		}
+		if tool.Timeout == 0 {
+			tool.Timeout = defaults.Timeout
+		}
+		if tool.ChecksumPolicy == "" {
+			tool.ChecksumPolicy = defaults.ChecksumPolicy
+		}
+		if tool.InstallMode == "" {
+			tool.InstallMode = defaults.InstallMode
+		}
+
+		config.Tools[name] = tool
+	}
+}
+
+func (config *Configuration) getExcludeAssets() []string {
+	if config.ExcludeAssets == nil {
+		return defaultExcludeAssets
+	}
+
+	return config.ExcludeAssets
+}
+
+// Returns the names of all tools, sorted
+func (config *Configuration) getToolNames() []string {
+	result := make([]string, 0, len(config.Tools))
+	for name := range config.Tools {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+// Disabled tools stay in the configuration but are skipped by install and check
+func (tool *Tool) isEnabled() bool {
+	return tool.Enabled == nil || *tool.Enabled
+}
+
+func (tool *Tool) hasTag(tag string) bool {
+	return slices.Contains(tool.Tags, tag)
+}
+
+// Replaces every '@tag' in the given tool specifications with the names of
+// the tools that have the tag. Every tool is only returned once, a tool given
+// by name takes precedence over the same tool of a tag. The second result
+// holds the tools that were only selected through a tag.
+func (config *Configuration) expandToolSpecs(specs []string) ([]string, map[string]bool, error) {
+	named := make(map[string]bool)
+	for _, spec := range specs {
+		if !strings.HasPrefix(spec, "@") {
+			name, _ := parseToolSpec(spec)
+			named[name] = true
+		}
+	}
+
+	var result []string
+	tagged := make(map[string]bool)
+	seen := make(map[string]bool)
+	for _, spec := range specs {
+		tag, isTag := strings.CutPrefix(spec, "@")
+		if !isTag {
+			name, _ := parseToolSpec(spec)
+			if !seen[name] {
+				seen[name] = true
+				result = append(result, spec)
+			}
+			continue
+		}
+
+		found := false
+		for _, name := range config.getToolNames() {
+			tool := config.Tools[name]
+			if !tool.hasTag(tag) {
+				continue
+			}
+
+			found = true
+			if !named[name] && !seen[name] {
+				seen[name] = true
+				tagged[name] = true
+				result = append(result, name)
+			}
+		}
+
+		if !found {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return nil, nil, fmt.Errorf("No tool has the tag '%s'.", tag)
+		}
+	}
+
+	return result, tagged, nil
+}
+
+// Returns all problems that make the configuration unusable
+func (config *Configuration) getErrors() []error {
+	var result []error
+
+	for _, pattern := range config.getExcludeAssets() {
+		_, err := compileExcludeAssets([]string{pattern})
+		if err != nil {
+			result = append(result, err)
+		}
+	}
+
+	for _, name := range config.getToolNames() {
+		switch tool := config.Tools[name]; tool.InstallMode {
+		case "", installModeDirectory, installModeInstaller:
+		default:
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			result = append(result, fmt.Errorf("Invalid install_mode '%s' of tool '%s', expected 'directory', 'installer' or nothing", tool.InstallMode, name))
+		}
+	}
+
+	for _, host := range slices.Sorted(maps.Keys(config.Headers)) {
+		result = append(result, getHeaderErrors(fmt.Sprintf("host '%s'", host), config.Headers[host])...)
+	}
+
+	for _, name := range config.getToolNames() {
+		result = append(result, getHeaderErrors(fmt.Sprintf("tool '%s'", name), config.Tools[name].Headers)...)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(config.Profiles)) {
+		_, err := config.getProfileTools(name)
+		if err != nil {
+			result = append(result, err)
+		}
+	}
+
+	if config.Jobs < 0 {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		result = append(result, fmt.Errorf("Invalid jobs '%d', expected a positive number", config.Jobs))
+	}
+
+	if _, err := config.getRetryPolicy(); err != nil {
+		result = append(result, err)
+	}
+
+	if !isValidChecksumPolicy(config.getChecksumPolicy()) {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		result = append(result, fmt.Errorf("Invalid checksum_policy '%s', expected 'off', 'warn' or 'require'", config.ChecksumPolicy))
+	}
+
+	for _, name := range config.getToolNames() {
+		tool := config.Tools[name]
+		if tool.ChecksumPolicy != "" && !isValidChecksumPolicy(tool.ChecksumPolicy) {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			result = append(result, fmt.Errorf("Invalid checksum_policy '%s' of tool '%s', expected 'off', 'warn' or 'require'", tool.ChecksumPolicy, name))
+		}
+		if tool.Timeout < 0 {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			result = append(result, fmt.Errorf("Invalid timeout '%d' of tool '%s', expected a positive number of seconds", tool.Timeout, name))
+		}
+	}
+
+	return result
+}
+
+func getConfig(path string) (Configuration, error) {
+	config, err := loadConfiguration(path)
+	if err != nil {
+		return config, err
+	}
+
+	if problems := config.getErrors(); len(problems) > 0 {
+		return config, problems[0]
+	}
+
+	err = config.applyProfile()
+	if err != nil {
+		return config, err
+	}
+
+	config.excludeRegexes, err = compileExcludeAssets(config.getExcludeAssets())
+	if err != nil {
+		return config, err
+	}
 
 	if runtime.GOOS == "windows" {
 		for k, v := range config.Tools {
@@ -59,7 +661,25 @@ func getConfig(path string) (Configuration, error) {
 	return config, err
 }
 
+// Binaries are installed per user, on Windows into the directory where per user
+// programs are usually installed
+func getDefaultInstallationDirectory() string {
+	if runtime.GOOS == "windows" {
+		return `%LOCALAPPDATA%\Programs\tooli\bin`
+	}
+
+	return "~/.local/bin"
+}
+
+// Returns the default configuration with the installation directory of the platform
+func getDefaultConfiguration() string {
+	installDirectory, _ := json.Marshal(getDefaultInstallationDirectory())
+
+	return strings.Replace(defaultConfiguration, `"install_dir": "~/.local/bin"`, `"install_dir": `+string(installDirectory), 1)
+}
+
 const defaultConfiguration = `{
+	"schema_version": 2,
 	"install_dir": "~/.local/bin",
 	"tools": {
 		"bat": {
@@ -247,26 +867,38 @@ const defaultConfiguration = `{
 	}
 }`
 
+// Writes the default configuration as JSON, or as TOML if the path ends in .toml
 func writeDefaultConfiguration(path *string) error {
 	filePath := replaceTildePath(*path)
 	dirName := filepath.Dir(filePath)
 
+	content := []byte(getDefaultConfiguration())
+	if isTomlFile(filePath) {
+		var err error
+		content, err = convertToToml(content)
+		if err != nil {
+			return err
+		}
+	}
+
 	err := os.MkdirAll(dirName, 0755)
 	if err != nil {
 		return err
 	}
 
 	_, err = os.Stat(filePath)
-	if err == nil {
+	if err == nil && assumeYes {
+		return os.WriteFile(filePath, content, 0644)
+	} else if err == nil {
 		fmt.Print("A file already exists at that location. Overwrite? [y/N]")
 		var input string
 		fmt.Scan(&input)
 		if input != "" && (input[0] == 121 || input[0] == 89) {
-			return os.WriteFile(filePath, []byte(defaultConfiguration), 0644)
+			return os.WriteFile(filePath, content, 0644)
 		}
 
 		return nil
 	} else {
-		return os.WriteFile(filePath, []byte(defaultConfiguration), 0644)
+		return os.WriteFile(filePath, content, 0644)
 	}
 }
@@ -0,0 +1,172 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Signature formats written by 'cosign sign-blob', either just the base64
+// encoded signature or one of the two bundle formats
+type CosignBundle struct {
+	// Bundle written with '--bundle'
+	Base64Signature string `json:"base64Signature"`
+	// Sigstore bundle written with '--new-bundle-format'
+	MessageSignature *struct {
+		Signature string `json:"signature"`
+	} `json:"messageSignature"`
+}
+
+// Reads the public key given either inline as PEM or as the path to a PEM file
+func readCosignPublicKey(key string) (crypto.PublicKey, error) {
+	content := []byte(key)
+	if !strings.HasPrefix(strings.TrimSpace(key), "-----BEGIN") {
+		var err error
+		content, err = os.ReadFile(expandPath(key))
+		if err != nil {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return nil, fmt.Errorf("Could not read cosign public key '%s': %v", key, err)
+		}
+	}
+
+	block, _ := pem.Decode(content)
+	if block == nil {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return nil, fmt.Errorf("Invalid cosign public key, expected a PEM encoded key.")
+	}
+
+	result, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return nil, fmt.Errorf("Invalid cosign public key: %v", err)
+	}
+
+	return result, nil
+}
+
+func parseCosignSignature(content []byte) ([]byte, error) {
+	encoded := strings.TrimSpace(string(content))
+
+	if strings.HasPrefix(encoded, "{") {
+		var bundle CosignBundle
+		err := json.Unmarshal(content, &bundle)
+		if err != nil {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return nil, fmt.Errorf("Invalid cosign bundle: %v", err)
+		}
+
+		encoded = bundle.Base64Signature
+		if bundle.MessageSignature != nil {
+			encoded = bundle.MessageSignature.Signature
+		}
+	}
+
+	result, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(result) == 0 {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return nil, fmt.Errorf("Invalid cosign signature.")
+	}
+
+	return result, nil
+}
+
+func verifyCosignSignature(publicKey crypto.PublicKey, data io.Reader, signature []byte) error {
+	// Ed25519 signs the data itself, the other algorithms sign its digest
+	if key, ok := publicKey.(ed25519.PublicKey); ok {
+		content, err := io.ReadAll(data)
+		if err != nil {
+			return err
+		}
+		if !ed25519.Verify(key, content, signature) {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return fmt.Errorf("The signature does not match.")
+		}
+		return nil
+	}
+
+	digest := sha256.New()
+	_, err := io.Copy(digest, data)
+	if err != nil {
+		return err
+	}
+	hash := digest.Sum(nil)
+
+	valid := false
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		valid = ecdsa.VerifyASN1(key, hash, signature)
+	case *rsa.PublicKey:
+		valid = rsa.VerifyPKCS1v15(key, crypto.SHA256, hash, signature) == nil ||
+			rsa.VerifyPSS(key, crypto.SHA256, hash, signature, nil) == nil
+	default:
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("Unsupported cosign public key type %T.", publicKey)
+	}
+
+	if !valid {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("The signature does not match.")
+	}
+
+	return nil
+}
+
+// Returns the first asset of the release that is named like the asset with one
+// of the suffixes, in the order of the suffixes
+func findCosignAsset(release *Release, asset *Asset, suffixes []string) *Asset {
+	for _, suffix := range suffixes {
+		for i := range release.Assets {
+			if release.Assets[i].Name == asset.Name+suffix {
+				return &release.Assets[i]
+			}
+		}
+	}
+
+	return nil
+}
+
+// Downloads the cosign signature or bundle of the asset and verifies it with
+// the tool's public key. Keyless signatures are verified by
+// verifyKeylessCosignAsset instead.
+func verifyCosignAsset(source Source, release *Release, asset *Asset, data *io.SectionReader, key string) error {
+	publicKey, err := readCosignPublicKey(key)
+	if err != nil {
+		return err
+	}
+
+	signatureAsset := findCosignAsset(release, asset, []string{".sigstore.json", ".bundle", ".sig"})
+	if signatureAsset == nil {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("A cosign public key is configured, but the release contains no signature for '%s'.", asset.Name)
+	}
+
+	content, err := readAsset(source, signatureAsset)
+	if err != nil {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("Could not download the signature '%s': %v", signatureAsset.Name, err)
+	}
+
+	signature, err := parseCosignSignature(content)
+	if err == nil {
+		err = verifyCosignSignature(publicKey, readFromStart(data), signature)
+	}
+	if err != nil {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("Could not verify '%s' with '%s': %v", asset.Name, signatureAsset.Name, err)
+	}
+
+	logInfo("Verified the cosign signature of '%s'.", asset.Name)
+	return nil
+}
@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const arHeaderSize = 60
+
+// Returns the members of an ar archive, which is the container format of
+// Debian packages, keyed by their names
+func readArArchive(data *io.SectionReader) (map[string]*io.SectionReader, error) {
+	magic := make([]byte, len(debMagic))
+	_, err := data.ReadAt(magic, 0)
+	if err != nil || !bytes.Equal(magic, debMagic) {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return nil, errors.New("Invalid Debian package, it is not an ar archive.")
+	}
+
+	result := make(map[string]*io.SectionReader)
+	offset := int64(len(debMagic))
+	header := make([]byte, arHeaderSize)
+	for offset+arHeaderSize <= data.Size() {
+		_, err = data.ReadAt(header, offset)
+		if err != nil {
+			return nil, err
+		}
+
+		// GNU ar terminates names with '/'
+		name := strings.TrimSuffix(strings.TrimSpace(string(header[0:16])), "/")
+		size, err := strconv.ParseInt(strings.TrimSpace(string(header[48:58])), 10, 64)
+		if err != nil || size < 0 || string(header[58:60]) != "`\n" {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return nil, fmt.Errorf("Invalid Debian package, the header of member '%s' is corrupt.", name)
+		}
+
+		offset += arHeaderSize
+		if offset+size > data.Size() {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return nil, fmt.Errorf("Invalid Debian package, member '%s' is truncated.", name)
+		}
+		result[name] = io.NewSectionReader(data, offset, size)
+
+		// Members are aligned to two bytes
+		offset += size + size%2
+	}
+
+	return result, nil
+}
+
+// Walks the files of a Debian package, which are in its data.tar member
+func walkDeb(data *io.SectionReader) (archiveWalker, error) {
+	members, err := readArArchive(data)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, content := range members {
+		switch name {
+		case "data.tar.gz":
+			return walkTarGz(content)
+		case "data.tar.xz":
+			return walkTarXz(content)
+		case "data.tar.zst":
+			return walkTar(func() (io.ReadCloser, error) {
+				decoder, err := zstd.NewReader(readFromStart(content))
+				if err != nil {
+					return nil, err
+				}
+				return decoder.IOReadCloser(), nil
+			}), nil
+		case "data.tar":
+			return walkPlainTar(content), nil
+		}
+	}
+
+	//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+	return nil, errors.New("The Debian package contains no supported data archive.")
+}
@@ -0,0 +1,144 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// Replaces the placeholders of a tool's url_template, e.g.
+// https://example.com/tool-{version}-{os}-{arch}.tar.gz
+func expandUrlTemplate(template string, version string) string {
+	replacer := strings.NewReplacer(
+		"{version}", strings.TrimPrefix(version, "v"),
+		"{tag}", version,
+		"{os}", runtime.GOOS,
+		"{arch}", runtime.GOARCH,
+	)
+
+	return replacer.Replace(template)
+}
+
+// Extracts the version from the response of a tool's version_url. Without a
+// version_regex the whole (trimmed) response is the version, otherwise the
+// first capture group (or the whole match if there is none) is used.
+func parseVersion(body string, versionRegex string) (string, error) {
+	if versionRegex == "" {
+		version := strings.TrimSpace(body)
+		if version == "" || strings.ContainsAny(version, " \t\n") {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return "", errors.New("The version URL did not return a plain version. Set 'version_regex' to extract it.")
+		}
+		return version, nil
+	}
+
+	regex, err := regexp.Compile(versionRegex)
+	if err != nil {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return "", fmt.Errorf("Invalid regular expression '%s' in 'version_regex': %v", versionRegex, err)
+	}
+
+	match := regex.FindStringSubmatch(body)
+	if match == nil {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return "", fmt.Errorf("The 'version_regex' '%s' did not match the response of the version URL.", versionRegex)
+	}
+
+	if len(match) > 1 {
+		return match[1], nil
+	}
+
+	return match[0], nil
+}
+
+func getUrlFileName(rawUrl string) string {
+	parsed, err := url.Parse(rawUrl)
+	if err != nil {
+		return path.Base(rawUrl)
+	}
+
+	return path.Base(parsed.Path)
+}
+
+// Source for tools that are not hosted on a forge, the release is built from the
+// tool's url_template with a single asset
+type UrlSource struct {
+	client *Downloader
+	tool   *Tool
+	// The token of the url_template's host
+	token string
+}
+
+// Returns the token for a request to the given URL. The version_url is often
+// on another host than the assets, which must not receive their token.
+func (source *UrlSource) getToken(link string) (string, error) {
+	host := getUrlHost(link)
+	if host == getToolHost(source.tool) {
+		return source.token, nil
+	}
+
+	return source.client.getHostToken(host)
+}
+
+func (source *UrlSource) ListReleases(limit int) ([]Release, error) {
+	release, err := source.GetLatest()
+	if err != nil {
+		return nil, err
+	}
+
+	return []Release{release}, nil
+}
+
+func (source *UrlSource) GetLatest() (Release, error) {
+	tool := source.tool
+	var result Release
+
+	if tool.VersionUrl == "" {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return result, errors.New("Tools with a 'url_template' also need a 'version_url'.")
+	}
+
+	token, err := source.getToken(tool.VersionUrl)
+	if err != nil {
+		return result, err
+	}
+
+	body, err := source.client.download(tool.VersionUrl, rtText, token)
+	if err != nil {
+		return result, err
+	}
+
+	version, err := parseVersion(string(body), tool.VersionRegex)
+	if err != nil {
+		return result, err
+	}
+
+	return source.GetByTag(version)
+}
+
+func (source *UrlSource) GetByTag(tag string) (Release, error) {
+	assetUrl := expandUrlTemplate(source.tool.UrlTemplate, tag)
+
+	return Release{TagName: tag, Assets: []Asset{{Name: getUrlFileName(assetUrl), BrowserDownloadUrl: assetUrl}}}, nil
+}
+
+func (source *UrlSource) OpenAsset(asset *Asset, offset int64) (io.ReadCloser, error) {
+	token, err := source.getToken(asset.BrowserDownloadUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	return source.client.openAsset(asset.BrowserDownloadUrl, token, offset)
+}
+
+func (source *UrlSource) DownloadSourceArchive(release *Release, file *os.File) error {
+	return errNoSourceArchive("direct URLs")
+}
@@ -0,0 +1,203 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// Words in asset names that stand for an architecture
+var architectureNames = map[string][]string{
+	"amd64": {"x86_64", "amd64", "x64", "64bit", "linux64", "win64"},
+	"arm64": {"aarch64", "arm64"},
+	"386":   {"i686", "i386", "386", "x86", "32bit"},
+	"arm":   {"armv7", "armhf", "arm"},
+}
+
+// Words in asset names that stand for an operating system
+var platformNames = map[string][]string{
+	"linux":   {"linux"},
+	"windows": {"windows", "win64", "win32", "win", "exe"},
+	"darwin":  {"darwin", "macos", "apple", "osx", "mac"},
+}
+
+// Asset endings from most to least preferred, the empty one is a plain binary
+var preferredEndings = []string{".tar.gz", ".tgz", ".tar.xz", ".txz", ".zip", ".7z", ".exe", ".gz", ".xz", ""}
+
+func isAlphanumeric(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('0' <= c && c <= '9')
+}
+
+// Whether the lowercase asset name contains one of the names as a whole word,
+// e.g. 'linux' in 'tool_linux_amd64' but not 'arm' in 'arm64'
+func containsAnyWord(assetName string, names []string) bool {
+	for _, name := range names {
+		for offset := 0; offset < len(assetName); {
+			index := strings.Index(assetName[offset:], name)
+			if index < 0 {
+				break
+			}
+
+			start := offset + index
+			end := start + len(name)
+			if (start == 0 || !isAlphanumeric(assetName[start-1])) && (end == len(assetName) || !isAlphanumeric(assetName[end])) {
+				return true
+			}
+			offset = start + 1
+		}
+	}
+
+	return false
+}
+
+func getAssetEndingRank(name string) int {
+	lowerName := strings.ToLower(name)
+	for i, ending := range preferredEndings {
+		if ending != "" && strings.HasSuffix(lowerName, ending) {
+			return i
+		}
+	}
+
+	if !strings.Contains(lowerName[max(0, len(lowerName)-5):], ".") {
+		return len(preferredEndings) - 1
+	}
+
+	return -1
+}
+
+// Guesses which asset of a release is meant for the platform, preferring
+// statically linked builds and the usual archive formats
+func guessPlatformAsset(assets []Asset, goos string, goarch string, config *Configuration) (Asset, bool) {
+	var result Asset
+	bestScore := -1
+
+	for _, asset := range assets {
+		if config.isExcludedAsset(asset.Name) {
+			continue
+		}
+
+		name := strings.ToLower(asset.Name)
+		if !containsAnyWord(name, platformNames[goos]) {
+			continue
+		}
+
+		otherArchitecture := false
+		for architecture, names := range architectureNames {
+			if architecture != goarch && containsAnyWord(name, names) && !containsAnyWord(name, architectureNames[goarch]) {
+				otherArchitecture = true
+			}
+		}
+		if otherArchitecture {
+			continue
+		}
+
+		rank := getAssetEndingRank(asset.Name)
+		if rank < 0 {
+			continue
+		}
+
+		score := 2 * (len(preferredEndings) - rank)
+		if containsAnyWord(name, architectureNames[goarch]) {
+			score += 100
+		}
+		if containsAnyWord(name, []string{"musl", "static"}) {
+			score += 50
+		}
+		if containsAnyWord(name, []string{"msvc"}) {
+			score += 10
+		}
+
+		if score > bestScore {
+			bestScore = score
+			result = asset
+		}
+	}
+
+	return result, bestScore >= 0
+}
+
+// Returns the part of the asset name after the version, which stays the same
+// in later releases, e.g. 'x86_64-unknown-linux-musl.tar.gz' for
+// 'bat-v0.24.0-x86_64-unknown-linux-musl.tar.gz'
+func getStableAssetSuffix(assetName string, version string) string {
+	version = strings.TrimPrefix(version, "v")
+	if version != "" {
+		if index := strings.LastIndex(assetName, version); index >= 0 {
+			suffix := strings.TrimLeft(assetName[index+len(version):], "-_.")
+			if suffix != "" {
+				return suffix
+			}
+		}
+	}
+
+	return assetName
+}
+
+// Returns the description of the repository, or nothing if it can not be obtained
+func getRepositoryDescription(downloader *Downloader, tool *Tool) string {
+	token, err := downloader.getToken(tool)
+	if err != nil {
+		return ""
+	}
+
+	url := fmt.Sprintf("%s/repos/%s/%s", githubApiUrl, tool.Owner, tool.Repository)
+	if !isGithubHost(tool.Host) {
+		url = (&GiteaSource{host: tool.Host, owner: tool.Owner, repository: tool.Repository}).getRepositoryUrl()
+	}
+
+	var repository struct {
+		Description string `json:"description"`
+	}
+	err = downloader.downloadJson(url, token, &repository)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(repository.Description)
+}
+
+// Fills in the entries of a tool from its repository: the description, the
+// asset suffixes for Linux and Windows from the latest release and the
+// repository's name as the binary
+func discoverTool(downloader *Downloader, tool *Tool, config *Configuration) error {
+	source, err := downloader.getSource(tool)
+	if err != nil {
+		return err
+	}
+
+	release, err := source.GetLatest()
+	if err != nil {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("Could not obtain the latest release of '%s': %w", getToolLink(tool), err)
+	}
+
+	goarch := runtime.GOARCH
+	if _, known := architectureNames[goarch]; !known {
+		goarch = "amd64"
+	}
+
+	for _, platform := range []struct {
+		goos  string
+		value *string
+	}{{"linux", &tool.LinuxAsset}, {"windows", &tool.WindowsAsset}} {
+		if *platform.value != "" {
+			continue
+		}
+
+		if asset, found := guessPlatformAsset(release.Assets, platform.goos, goarch, config); found {
+			*platform.value = getStableAssetSuffix(asset.Name, release.TagName)
+		}
+	}
+
+	if tool.Description == "" {
+		tool.Description = getRepositoryDescription(downloader, tool)
+	}
+
+	if len(tool.Binaries) == 0 {
+		tool.Binaries = []Binary{{Name: strings.ToLower(tool.Repository)}}
+	}
+
+	return nil
+}
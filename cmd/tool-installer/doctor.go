@@ -0,0 +1,247 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+type GithubRateLimitResponse struct {
+	Resources struct {
+		Core struct {
+			Limit     int   `json:"limit"`
+			Remaining int   `json:"remaining"`
+			Reset     int64 `json:"reset"`
+		} `json:"core"`
+	} `json:"resources"`
+}
+
+// Collects the results of the checks of 'doctor'
+type DoctorReport struct {
+	failed bool
+}
+
+func (report *DoctorReport) pass(format string, args ...any) {
+	fmt.Printf("[ok]      "+format+"\n", args...)
+}
+
+// Reports a problem that does not prevent tooli from working, with how to fix it
+func (report *DoctorReport) warn(fix string, format string, args ...any) {
+	fmt.Printf("[warning] "+format+"\n", args...)
+	fmt.Printf("          Fix: %s\n", fix)
+}
+
+func (report *DoctorReport) fail(fix string, format string, args ...any) {
+	report.failed = true
+	fmt.Printf("[error]   "+format+"\n", args...)
+	fmt.Printf("          Fix: %s\n", fix)
+}
+
+func isSamePath(a string, b string) bool {
+	a = filepath.Clean(a)
+	b = filepath.Clean(b)
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(a, b)
+	}
+
+	return a == b
+}
+
+func isInPath(directory string) bool {
+	for _, entry := range filepath.SplitList(os.Getenv("PATH")) {
+		if entry != "" && isSamePath(expandPath(entry), directory) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func getPathFix(directory string) string {
+	if runtime.GOOS == "windows" {
+		return fmt.Sprintf("Add '%s' to the 'Path' environment variable of your user.", directory)
+	}
+
+	return fmt.Sprintf("Add 'export PATH=\"%s:$PATH\"' to your shell's profile, e.g. '~/.profile'.", directory)
+}
+
+func checkInstallationDirectory(report *DoctorReport, directory string) {
+	info, err := os.Stat(directory)
+	if os.IsNotExist(err) {
+		report.warn(fmt.Sprintf("Run 'tooli install', which creates it, or create it with 'mkdir -p %s'.", directory), "The installation directory '%s' does not exist.", directory)
+		return
+	}
+	if err != nil || !info.IsDir() {
+		report.fail("Set 'install_dir' in the configuration to a directory.", "The installation directory '%s' is not a directory.", directory)
+		return
+	}
+
+	file, err := os.CreateTemp(directory, ".tooli-doctor-*")
+	if err != nil {
+		report.fail("Make the directory writable for your user or set 'install_dir' to a directory you own.", "The installation directory '%s' is not writable: %v", directory, err)
+	} else {
+		file.Close()
+		os.Remove(file.Name())
+		report.pass("The installation directory '%s' exists and is writable.", directory)
+	}
+
+	if isInPath(directory) {
+		report.pass("The installation directory is in PATH.")
+	} else {
+		report.warn(getPathFix(directory), "The installation directory '%s' is not in PATH, installed tools cannot be run by name.", directory)
+	}
+}
+
+// Returns the recorded files of an installed tool that do not exist
+func getMissingFiles(name string, cache *Cache, config *Configuration) []string {
+	var missing []string
+	for _, file := range cache.Installed[name].Files {
+		if _, err := os.Lstat(getInstalledFilePath(config.InstallationDirectory, file)); os.IsNotExist(err) {
+			missing = append(missing, file)
+		}
+	}
+
+	return missing
+}
+
+func checkInstalledFiles(report *DoctorReport, cache *Cache, config *Configuration) {
+	names := make([]string, 0, len(cache.Tools))
+	for name := range cache.Tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	complete := 0
+	for _, name := range names {
+		if _, found := config.Tools[name]; !found {
+			report.warn("Add it to the configuration again, or remove its files with 'tooli prune --yes'.", "Tool '%s' is installed but not in the configuration.", name)
+			continue
+		}
+
+		missing := getMissingFiles(name, cache, config)
+		if len(missing) > 0 {
+			report.fail(fmt.Sprintf("Run 'tooli install --force %s'.", name), "Tool '%s' is recorded as installed, but %s is missing.", name, strings.Join(missing, ", "))
+		} else {
+			complete++
+		}
+	}
+
+	report.pass("The files of %d of %d installed tools exist.", complete, len(names))
+}
+
+// Checks that the CLIs needed to verify keyless signatures and attestations
+// are installed, if any tool uses them
+func checkVerifiers(report *DoctorReport, config *Configuration) {
+	verifiers := map[string][]string{}
+	for _, name := range config.getToolNames() {
+		tool := config.Tools[name]
+		if tool.CosignIdentity != "" {
+			verifiers["cosign"] = append(verifiers["cosign"], name)
+		}
+		if tool.Attestation {
+			verifiers["gh"] = append(verifiers["gh"], name)
+		}
+	}
+
+	for _, verifier := range slices.Sorted(maps.Keys(verifiers)) {
+		if _, err := exec.LookPath(verifier); err != nil {
+			report.fail(fmt.Sprintf("Install '%s' and add it to the PATH.", verifier), "'%s' is needed to verify %s, but it was not found.", verifier, strings.Join(verifiers[verifier], ", "))
+		} else {
+			report.pass("'%s' is installed to verify signatures.", verifier)
+		}
+	}
+}
+
+func checkGithubToken(report *DoctorReport, downloader *Downloader) {
+	token, err := downloader.getToken(&Tool{})
+	if err != nil {
+		report.fail("Check the token configured for 'github.com'.", "The GitHub token could not be obtained: %v", err)
+		return
+	}
+
+	if token == "" {
+		report.warn("Set the 'GITHUB_TOKEN' environment variable, see the section 'Acess Token' of the README.", "No GitHub token is set, so only 60 requests per hour can be made.")
+	}
+
+	var response GithubRateLimitResponse
+	err = downloader.downloadJson(githubApiUrl+"/rate_limit", token, &response)
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusUnauthorized {
+		report.fail("Create a new token and set it in the 'GITHUB_TOKEN' environment variable.", "GitHub does not accept the token, it is invalid or expired.")
+		return
+	}
+	if err != nil {
+		report.fail("Check your network connection and proxy settings.", "Could not reach the GitHub API: %v", err)
+		return
+	}
+
+	core := response.Resources.Core
+	message := fmt.Sprintf("%d of %d GitHub API requests are left, the limit resets at %s.", core.Remaining, core.Limit, formatReset(time.Unix(core.Reset, 0)))
+	if core.Remaining == 0 {
+		report.warn("Wait until the limit resets or set a token to increase it.", "%s", message)
+	} else {
+		report.pass("%s", message)
+	}
+}
+
+// Checks the configuration, cache, installation directory and GitHub access
+// for common problems and prints how to fix them. Returns false if there are
+// problems that keep tooli from working.
+func runDoctor(configLocation *string, downloadTimeout int) bool {
+	report := DoctorReport{}
+
+	config, err := getConfig(*configLocation)
+	if err != nil {
+		report.fail("Run 'tooli config validate' for details, or create a new configuration with 'tooli create-config'.", "The configuration '%s' could not be loaded: %v", *configLocation, err)
+	} else {
+		report.pass("The configuration '%s' is valid.", *configLocation)
+		for _, problem := range getConfigurationProblems(&config) {
+			report.warn("Run 'tooli config edit' to correct the entry.", "%s.", problem)
+		}
+	}
+
+	configLoaded := err == nil
+	if configLoaded {
+		checkInstallationDirectory(&report, config.InstallationDirectory)
+		checkVerifiers(&report, &config)
+	}
+
+	cacheFilePath, _ := getCacheFilePath()
+	cache, err := getCache()
+	if err != nil {
+		report.fail(fmt.Sprintf("Delete '%s', tooli creates a new one but then reinstalls all tools.", cacheFilePath), "The cache '%s' could not be read: %v", cacheFilePath, err)
+	} else {
+		report.pass("The cache '%s' is valid.", cacheFilePath)
+		if configLoaded {
+			checkInstalledFiles(&report, &cache, &config)
+		}
+	}
+
+	if configLoaded {
+		downloader, err := newDownloader(downloadTimeout, &config)
+		if err != nil {
+			report.fail("Check the proxy and certificate settings of the configuration.", "Could not set up downloads: %v", err)
+		} else {
+			checkGithubToken(&report, &downloader)
+		}
+	}
+
+	if report.failed {
+		fmt.Println("Found problems that need to be fixed.")
+	} else {
+		fmt.Println("No problems found.")
+	}
+
+	return !report.failed
+}
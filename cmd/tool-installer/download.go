@@ -3,20 +3,49 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"runtime"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 type Downloader struct {
-	client      http.Client
-	githubToken string
+	client             http.Client
+	githubToken        string
+	hostTokens         map[string]TokenSource
+	credentialFallback bool
+	mirrors            map[string]string
+	hostHeaders        map[string]map[string]string
+	toolHeaders        map[string]string
+	toolHeadersHost    string
+	tokenCache         map[string]string
+	rateLimits         map[string]RateLimit
+	progress           *ProgressDisplay
+	retry              RetryPolicy
+	// Limits the bandwidth of asset downloads, nil for no limit
+	throttle *Throttle
+	// Tags of the latest releases by repository, see prefetchLatestTags
+	latestTags map[string]string
+	// Where downloaded assets are kept, empty if there is no cache directory
+	downloadCache string
+	// Cancels all requests, see cancelOnInterrupt
+	context context.Context
+	// Guards the maps above while tools are installed in parallel
+	mutex *sync.Mutex
 }
 
 type RequestFormat int
@@ -24,6 +53,7 @@ type RequestFormat int
 const (
 	rtJson RequestFormat = iota
 	rtBinary
+	rtText
 )
 
 const rateLimitText = `Error: Got non-OK status code '%v'.
@@ -31,167 +61,697 @@ const rateLimitText = `Error: Got non-OK status code '%v'.
 This most likely means that you hit Github's API rate limit. To increase the number of requests you can make, set the 'GITHUB_TOKEN' environment variable.
 `
 
-func newDownloader(timeoutSeconds int) Downloader {
+// Limits of the connections that are kept open for later requests. Parallel
+// installs send many small requests to the same API host, so more than the
+// default of 2 idle connections per host are kept.
+const (
+	maxIdleConnections        = 64
+	maxIdleConnectionsPerHost = 16
+	idleConnectionTimeout     = 90 * time.Second
+	// Unused response bodies up to this size are read so the connection can be reused
+	maxDiscardedBytes = 64 * 1024
+)
+
+// The transport of the first downloader, which all later downloaders share so
+// that they reuse its open connections
+var sharedTransport struct {
+	mutex     sync.Mutex
+	transport *http.Transport
+}
+
+func getSharedTransport(config *Configuration) (*http.Transport, error) {
+	sharedTransport.mutex.Lock()
+	defer sharedTransport.mutex.Unlock()
+
+	if sharedTransport.transport != nil {
+		return sharedTransport.transport, nil
+	}
+
+	transport, err := newTransport(config)
+	if err != nil {
+		return nil, err
+	}
+
+	sharedTransport.transport = transport
+	return transport, nil
+}
+
+func newTransport(config *Configuration) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = maxIdleConnections
+	transport.MaxIdleConnsPerHost = maxIdleConnectionsPerHost
+	transport.IdleConnTimeout = idleConnectionTimeout
+	// Setting TLSClientConfig below disables HTTP/2 unless it is forced
+	transport.ForceAttemptHTTP2 = true
+
+	if config.Proxy != "" {
+		proxyUrl, err := url.Parse(config.Proxy)
+		if err != nil {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return nil, fmt.Errorf("Invalid proxy URL '%s': %v", config.Proxy, err)
+		}
+		transport.Proxy = http.ProxyURL(proxyUrl)
+	}
+
+	if config.CaCertificate == "" && !config.InsecureSkipVerify {
+		return transport, nil
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: config.InsecureSkipVerify}
+
+	if config.CaCertificate != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		pem, err := os.ReadFile(expandPath(config.CaCertificate))
+		if err != nil {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return nil, fmt.Errorf("Could not read CA certificate: %v", err)
+		}
+
+		if !pool.AppendCertsFromPEM(pem) {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return nil, fmt.Errorf("No valid PEM certificates found in '%s'.", config.CaCertificate)
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	transport.TLSClientConfig = tlsConfig
+
+	return transport, nil
+}
+
+func newDownloader(timeoutSeconds int, config *Configuration) (Downloader, error) {
 	githubToken := os.Getenv("GITHUB_TOKEN")
 
-	res := Downloader{client: http.Client{Timeout: time.Duration(timeoutSeconds) * time.Second}, githubToken: githubToken}
+	transport, err := getSharedTransport(config)
+	if err != nil {
+		return Downloader{}, err
+	}
+
+	retry, err := config.getRetryPolicy()
+	if err != nil {
+		return Downloader{}, err
+	}
+
+	if config.InsecureSkipVerify {
+		logWarning("TLS certificate verification is disabled.")
+	}
+
+	downloadCache, _ := config.getDownloadCache()
+
+	res := Downloader{
+		client:             http.Client{Timeout: time.Duration(timeoutSeconds) * time.Second, Transport: transport},
+		githubToken:        githubToken,
+		hostTokens:         config.Tokens,
+		credentialFallback: config.CredentialFallback,
+		mirrors:            config.Mirrors,
+		hostHeaders:        config.Headers,
+		tokenCache:         make(map[string]string),
+		rateLimits:         make(map[string]RateLimit),
+		latestTags:         make(map[string]string),
+		progress:           newProgressDisplay(),
+		retry:              retry,
+		downloadCache:      downloadCache,
+		context:            context.Background(),
+		mutex:              &sync.Mutex{},
+	}
+	res.client.CheckRedirect = res.checkRedirect
 
-	return res
+	return res, nil
 }
 
-func (client *Downloader) newRequest(url string, requestFormat RequestFormat) (*http.Request, error) {
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+// Returns a copy of the downloader with another request timeout, used for the
+// timeout of a single tool
+func (client *Downloader) withTimeout(timeout time.Duration) *Downloader {
+	result := *client
+	result.client.Timeout = timeout
+
+	return &result
+}
+
+// Rewrites the URL through the configured mirror with the longest matching
+// prefix, if there is one
+func (client *Downloader) rewriteUrl(url string) string {
+	longest := ""
+	for prefix := range client.mirrors {
+		if strings.HasPrefix(url, prefix) && len(prefix) > len(longest) {
+			longest = prefix
+		}
+	}
+
+	if longest == "" {
+		return url
+	}
+
+	return client.mirrors[longest] + strings.TrimPrefix(url, longest)
+}
+
+// The token belongs to the host of the given URL. If a mirror sends the
+// request to another host, the token of the mirror's host is used instead, so
+// that it never receives the token of the original host.
+func (client *Downloader) newRequest(method string, url string, requestFormat RequestFormat, token string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(client.context, method, client.rewriteUrl(url), body)
 	if err != nil {
 		return nil, err
 	}
 
+	if token != "" && req.URL.Host != getUrlHost(url) {
+		token, err = client.getHostToken(req.URL.Host)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	switch requestFormat {
 	case rtJson:
 		req.Header.Add("Accept", "application/vnd.github+json")
 	case rtBinary:
 		req.Header.Add("Accept", "application/octet-stream")
+	case rtText:
+		req.Header.Add("Accept", "text/plain, application/json, */*")
 	default:
 		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
 		return nil, errors.New("Invalid request type")
 	}
 
 	req.Header.Add("User-Agent", userAgent)
-	if client.githubToken != "" {
-		req.Header.Add("Authorization", fmt.Sprintf("token %s", client.githubToken))
+	if token != "" {
+		req.Header.Add("Authorization", fmt.Sprintf("token %s", token))
 	}
 
+	client.addHeaders(req)
+
 	return req, nil
 }
 
-func (client *Downloader) downloadRelease(owner string, repository string) (Release, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/latest", owner, repository)
-
-	var result Release
-
-	req, err := client.newRequest(url, rtJson)
-	if err != nil {
-		return result, err
-	}
-
+// Sends the request, logging it in debug mode
+func (client *Downloader) do(req *http.Request) (*http.Response, error) {
+	start := time.Now()
 	resp, err := client.client.Do(req)
 	if err != nil {
-		return result, err
+		logDebug("%s %s failed after %v: %v", req.Method, req.URL.Redacted(), time.Since(start).Round(time.Millisecond), err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
-		return result, fmt.Errorf(rateLimitText, resp.StatusCode)
-	}
+	logDebug("%s %s: %s, %s after %v", req.Method, req.URL.Redacted(), resp.Status, resp.Proto, time.Since(start).Round(time.Millisecond))
+	return resp, nil
+}
 
-	body, err := io.ReadAll(resp.Body)
+// Closes a response whose body is not used, reading the rest of it first so
+// that the connection can be reused for the next request
+func discardResponse(resp *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxDiscardedBytes))
+	resp.Body.Close()
+}
+
+// Downloads the given URL and decodes the JSON response into result
+func (client *Downloader) downloadJson(url string, token string, result any) error {
+	body, err := client.download(url, rtJson, token)
 	if err != nil {
-		return result, err
+		return err
 	}
 
-	err = json.Unmarshal(body, &result)
+	return json.Unmarshal(body, result)
+}
+
+// Sends the payload as JSON and decodes the JSON response into result
+func (client *Downloader) postJson(url string, token string, payload any, result any) error {
+	content, err := json.Marshal(payload)
 	if err != nil {
-		return result, err
+		return err
 	}
 
-	return result, nil
+	return client.withRetries(fmt.Sprintf("Sending a request to '%s'", url), func() error {
+		req, err := client.newRequest(http.MethodPost, url, rtJson, token, bytes.NewReader(content))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		// Not counted against the host's rate limit, GitHub's GraphQL API
+		// has a separate one
+		resp, err := client.do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			discardResponse(resp)
+			return &StatusError{StatusCode: resp.StatusCode}
+		}
+
+		return json.NewDecoder(resp.Body).Decode(result)
+	})
+}
+
+// Downloads the asset into the file without keeping it in memory, the file
+// is emptied before every attempt
+func (client *Downloader) downloadAssetToFile(url string, token string, file *os.File) error {
+	return client.withRetries(fmt.Sprintf("Downloading '%s'", url), func() error {
+		body, err := client.openAsset(url, token, 0)
+		if err != nil {
+			return err
+		}
+		defer body.Close()
+
+		_, err = file.Seek(0, io.SeekStart)
+		if err == nil {
+			err = file.Truncate(0)
+		}
+		if err == nil {
+			_, err = io.Copy(file, body)
+		}
+		return err
+	})
 }
 
-func (client *Downloader) downloadAsset(url string) ([]byte, error) {
+// Returns the body of the asset for streaming, which the caller closes. With
+// an offset, only the rest of the asset is requested, see isPartialResponse.
+func (client *Downloader) openAsset(url string, token string, offset int64) (io.ReadCloser, error) {
+	return client.openFrom(url, rtBinary, token, offset)
+}
+
+func (client *Downloader) download(url string, requestFormat RequestFormat, token string) ([]byte, error) {
 	var result []byte
+	err := client.withRetries(fmt.Sprintf("Downloading '%s'", url), func() error {
+		body, err := client.open(url, requestFormat, token)
+		if err != nil {
+			return err
+		}
+		defer body.Close()
 
-	req, err := client.newRequest(url, rtBinary)
-	if err != nil {
-		return result, err
+		result, err = io.ReadAll(body)
+		return err
+	})
+
+	return result, err
+}
+
+func (client *Downloader) open(url string, requestFormat RequestFormat, token string) (io.ReadCloser, error) {
+	return client.openFrom(url, requestFormat, token, 0)
+}
+
+func (client *Downloader) openFrom(url string, requestFormat RequestFormat, token string, offset int64) (io.ReadCloser, error) {
+	if offlineMode {
+		return nil, errOffline(url)
 	}
 
-	resp, err := client.client.Do(req)
+	req, err := client.newRequest(http.MethodGet, url, requestFormat, token, nil)
 	if err != nil {
-		return result, err
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
-		return result, fmt.Errorf(rateLimitText, resp.StatusCode)
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
 	}
 
-	result, err = io.ReadAll(resp.Body)
+	err = client.checkRateLimit(req.URL.Host)
 	if err != nil {
-		return result, err
+		return nil, err
 	}
 
-	return result, nil
-}
+	var resp *http.Response
+	if requestFormat == rtBinary {
+		resp, err = client.doAsset(req)
+	} else {
+		resp, err = client.do(req)
+	}
+	if err != nil {
+		return nil, err
+	}
 
-func (client *Downloader) downloadTool(name string, config *Configuration, cache *Cache) error {
+	client.updateRateLimit(resp)
 
-	tool, found := config.Tools[name]
-	if !found {
-		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
-		return fmt.Errorf("Tool '%s' not found in configuration.", name)
+	if rateLimit, found := client.getRateLimit(req.URL.Host); found && rateLimit.Remaining == 0 && resp.StatusCode != http.StatusOK {
+		discardResponse(resp)
+		return nil, rateLimitExceededError(req.URL.Host, rateLimit)
 	}
 
-	release, err := client.downloadRelease(tool.Owner, tool.Repository)
-	if err != nil {
-		return err
+	partial := offset > 0 && resp.StatusCode == http.StatusPartialContent
+	if resp.StatusCode != http.StatusOK && !partial {
+		discardResponse(resp)
+		return nil, &StatusError{StatusCode: resp.StatusCode}
 	}
 
-	currentVersion, found := cache.Tools[name]
-	if found && currentVersion == release.TagName {
-		fmt.Printf("Skipping asset download for '%v' because it is already installed and up to date.", name)
-		return nil
-	}
+	return &ResponseBody{ReadCloser: resp.Body, contentLength: resp.ContentLength, partial: partial}, nil
+}
 
-	var asset string
+func getPlatformAsset(tool *Tool) (string, error) {
 	switch os := runtime.GOOS; os {
 	case "linux":
-		asset = tool.LinuxAsset
+		return tool.LinuxAsset, nil
 	case "windows":
-		asset = tool.WindowsAsset
+		return tool.WindowsAsset, nil
 	default:
 		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
-		return fmt.Errorf("The platform '%s' is not supported", os)
+		return "", fmt.Errorf("The platform '%s' is not supported", os)
+	}
+}
+
+// Whether the asset name marks a statically linked build, e.g. musl builds
+func isStaticAsset(name string) bool {
+	name = strings.ToLower(name)
+
+	return strings.Contains(name, "musl") || strings.Contains(name, "static")
+}
+
+func selectAsset(release *Release, tool *Tool, config *Configuration) (Asset, error) {
+	// Direct-URL tools always have exactly one asset, built from the template
+	if tool.UrlTemplate != "" {
+		return release.Assets[0], nil
+	}
+
+	asset, err := getPlatformAsset(tool)
+	if err != nil {
+		return Asset{}, err
+	}
+
+	// OCI artifacts and gists often consist of just the binary, so no name is needed
+	if asset == "" && (tool.OciImage != "" || tool.Gist != "") && len(release.Assets) == 1 {
+		return release.Assets[0], nil
 	}
 
 	if asset == "" {
 		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
-		return errors.New("No asset name provided for the current platform.")
+		return Asset{}, errors.New("No asset name provided for the current platform.")
 	}
 
+	// Asset names that are excluded themselves, e.g. '_amd64.deb', explicitly ask for such assets
+	applyExclusions := !config.isExcludedAsset(asset)
+
 	var res []Asset
 	for _, a := range release.Assets {
+		if applyExclusions && config.isExcludedAsset(a.Name) {
+			logVerbose("Ignoring the asset '%s' because it is excluded.", a.Name)
+			continue
+		}
 		if strings.HasSuffix(a.Name, asset) {
 			if tool.AssetPrefix == "" {
 				res = append(res, a)
 			} else if strings.HasPrefix(a.Name, tool.AssetPrefix) {
 				res = append(res, a)
+			} else {
+				logVerbose("Ignoring the asset '%s' because it does not start with '%s'.", a.Name, tool.AssetPrefix)
 			}
 		}
 	}
+	for _, a := range res {
+		logVerbose("The asset '%s' of release %s ends with '%s'.", a.Name, release.TagName, asset)
+	}
 
 	if len(res) == 0 {
 		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
-		return errors.New("Could not find a matching asset. Did you forget to include one in the config?")
+		return Asset{}, errors.New("Could not find a matching asset. Did you forget to include one in the config?")
+	}
+	if len(res) > 1 && tool.prefersStatic() {
+		var static []Asset
+		for _, a := range res {
+			if isStaticAsset(a.Name) {
+				static = append(static, a)
+			}
+		}
+		if len(static) == 1 {
+			logVerbose("Selecting the asset '%s' because it is statically linked.", static[0].Name)
+			return static[0], nil
+		}
 	}
 	if len(res) > 1 {
 		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
-		return errors.New("Found two or more matching assets. Please be more specific.")
+		return Asset{}, errors.New("Found two or more matching assets. Please be more specific.")
+	}
+
+	return res[0], nil
+}
+
+// Overrides for a single installation, empty values use the configuration
+type InstallOptions struct {
+	Version string
+	// Name and digest the asset must have, e.g. from a lockfile
+	Asset  string
+	Sha256 string
+	// Allows installing a version older than the installed one
+	AllowDowngrade bool
+	// Releases younger than this are not installed
+	MinAge time.Duration
+	// Prints the release notes since the installed version before installing
+	ShowChangelog bool
+	// Refuses assets whose signature cannot be verified
+	RequireSigned bool
+	// Installs the release again if it is already installed, e.g. after the
+	// installed files were deleted
+	Force bool
+}
+
+// Parses durations like '7d', '2w' or '12h'
+func parseAge(age string) (time.Duration, error) {
+	units := map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+
+	for suffix, unit := range units {
+		if number, found := strings.CutSuffix(age, suffix); found {
+			count, err := strconv.Atoi(number)
+			if err == nil && count >= 0 {
+				return time.Duration(count) * unit, nil
+			}
+		}
+	}
+
+	result, err := time.ParseDuration(age)
+	if err != nil || result < 0 {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return 0, fmt.Errorf("Invalid age '%s', expected e.g. '7d', '2w' or '12h'.", age)
+	}
+
+	return result, nil
+}
+
+// Returns true if the release is known to be younger than minAge
+func isTooRecent(release *Release, minAge time.Duration) bool {
+	if minAge <= 0 {
+		return false
+	}
+
+	published, err := time.Parse(time.RFC3339, release.PublishedAt)
+	if err != nil {
+		return false
+	}
+
+	return time.Since(published) < minAge
+}
+
+func isDowngrade(installed string, candidate string) bool {
+	installedVersion, ok := parseSemver(normalizeVersion(installed))
+	if !ok {
+		return false
+	}
+
+	candidateVersion, ok := parseSemver(normalizeVersion(candidate))
+	if !ok {
+		return false
+	}
+
+	return candidateVersion.Compare(installedVersion) < 0
+}
+
+func (client *Downloader) downloadTool(name string, options InstallOptions, config *Configuration, cache *Cache) error {
+
+	tool, found := config.Tools[name]
+	if !found {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("Tool '%s' not found in configuration.", name)
+	}
+
+	if options.Version != "" {
+		tool.Version = options.Version
+	}
+
+	err := checkTrust(name, &tool, config, cache)
+	if err != nil {
+		return err
+	}
+
+	currentVersion, found := cache.getVersion(name)
+	if tag, prefetched := client.getPrefetchedTag(&tool); prefetched && found && !options.Force && isSameVersion(currentVersion, tag) {
+		logInfo("Skipping asset download for '%v' because it is already installed and up to date.", name)
+		return nil
+	}
+
+	source, err := client.getSource(&tool)
+	if err != nil {
+		return err
+	}
+
+	release, err := getToolRelease(source, &tool)
+	if err != nil {
+		return err
+	}
+
+	if found && !options.Force && isSameVersion(currentVersion, release.TagName) {
+		logInfo("Skipping asset download for '%v' because it is already installed and up to date.", name)
+		return nil
+	}
+
+	if options.Version == "" && isTooRecent(&release, options.MinAge) {
+		logInfo("Skipping '%s' because release %s was published on %s, which is too recent.", name, release.TagName, formatDate(release.PublishedAt))
+		return nil
+	}
+
+	if found && !options.AllowDowngrade && isDowngrade(currentVersion, release.TagName) {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("Refusing to downgrade '%s' from %s to %s. Use '--allow-downgrade' if this is intended.", name, currentVersion, release.TagName)
+	}
+
+	if options.ShowChangelog {
+		releases, err := getChangelogReleases(source, currentVersion, &release)
+		if err != nil {
+			logWarning("Could not obtain the changelog of '%s': %v", name, err)
+		} else {
+			printChangelog(name, releases)
+		}
+	}
+
+	if tool.BuildCommand != "" {
+		if options.RequireSigned {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return fmt.Errorf("Refusing to build '%s' because source archives cannot be verified.", name)
+		}
+
+		files, err := buildTool(source, &release, &tool, &config.InstallationDirectory)
+		if err != nil {
+			return err
+		}
+
+		return recordInstallation(name, release.TagName, InstalledTool{Files: files}, config, cache)
+	}
+
+	asset, err := selectAsset(&release, &tool, config)
+	if err != nil {
+		return err
+	}
+
+	if options.Asset != "" && asset.Name != options.Asset {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("Expected the asset '%s' but the configuration selects '%s'.", options.Asset, asset.Name)
+	}
+
+	partialFilePath, err := getPartialFilePath(name, &release, &asset)
+	if err != nil {
+		return err
 	}
 
-	assetUrl := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/assets/%d", tool.Owner, tool.Repository, res[0].Id)
+	assetSource, cached := getCachedAssetSource(source, &release, &asset)
+	if cached && !offlineMode {
+		logInfo("Using the cached download of '%s'.", asset.Name)
+	}
 
-	binaryContent, err := client.downloadAsset(assetUrl)
+	// The asset is hashed while it is stored in the partial file
+	hash := sha256.New()
+	var file *os.File
+	var binaryContent *io.SectionReader
+	err = client.withRetries(fmt.Sprintf("Downloading '%s'", asset.Name), func() error {
+		file, binaryContent, err = client.downloadToPartialFile(assetSource, &asset, name, partialFilePath, hash)
+		return err
+	})
 	if err != nil {
 		return err
 	}
+	defer removeTempFile(file)
 
-	err = extractFiles(binaryContent, &res[0], &tool, &config.InstallationDirectory)
+	digest := hex.EncodeToString(hash.Sum(nil))
+
+	if options.Sha256 != "" && !strings.EqualFold(digest, options.Sha256) {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("The SHA-256 digest of '%s' is %s, but %s was expected.", asset.Name, digest, options.Sha256)
+	}
+
+	pinnedDigest := tool.getPinnedSha256(release.TagName)
+	if pinnedDigest != "" && !strings.EqualFold(digest, pinnedDigest) {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("The SHA-256 digest of '%s' is %s, but the configuration pins %s.", asset.Name, digest, pinnedDigest)
+	}
+
+	err = verifyReleaseChecksum(source, &release, &asset, digest, config.getToolChecksumPolicy(&tool), options.Sha256 != "" || pinnedDigest != "")
 	if err != nil {
 		return err
 	}
 
-	cache.Tools[name] = release.TagName
+	if tool.MinisignPubkey != "" {
+		err = verifyMinisignAsset(source, &release, &asset, binaryContent, tool.MinisignPubkey)
+		if err != nil {
+			return err
+		}
+	}
+
+	if tool.GpgKey != "" {
+		err = verifyGpgAsset(source, &release, &asset, binaryContent, digest, tool.GpgKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	if tool.CosignPubkey != "" {
+		err = verifyCosignAsset(source, &release, &asset, binaryContent, tool.CosignPubkey)
+		if err != nil {
+			return err
+		}
+	}
+
+	if tool.CosignIdentity != "" {
+		err = verifyKeylessCosignAsset(source, &release, &asset, binaryContent, &tool)
+		if err != nil {
+			return err
+		}
+	}
+
+	if tool.Attestation {
+		err = verifyAttestation(&asset, binaryContent, &tool)
+		if err != nil {
+			return err
+		}
+	}
+
+	if options.RequireSigned && !tool.isSignatureVerified() {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("Refusing to install '%s' because no key to verify its signature is configured.", name)
+	}
+
+	var files []string
+	if tool.InstallMode == installModeInstaller {
+		files, err = storeInstaller(binaryContent, &asset, name, &tool, config)
+	} else {
+		files, err = extractFiles(binaryContent, &asset, name, &tool, config, &config.InstallationDirectory)
+	}
+	if err != nil {
+		return err
+	}
+
+	keepDownloadedAsset(source, &release, &asset, file)
+
+	return recordInstallation(name, release.TagName, InstalledTool{Asset: asset.Name, Sha256: digest, Files: files}, config, cache)
+}
+
+// Updates the cache after a successful installation and keeps a copy of the
+// installed files for rollbacks
+func recordInstallation(name string, version string, installed InstalledTool, config *Configuration, cache *Cache) error {
+	hashes, err := hashInstalledFiles(installed.Files, config.InstallationDirectory)
+	if err != nil {
+		logWarning("Could not record the digests of the files of '%s': %v", name, err)
+	}
+	installed.FileHashes = hashes
+
+	cacheMutex.Lock()
+	cache.Tools[name] = version
+	cache.Installed[name] = installed
+	cacheMutex.Unlock()
+
+	err = saveToStore(name, version, installed, config)
+	if err != nil {
+		logWarning("Could not keep a copy of '%s' for rollbacks: %v", name, err)
+	}
 
 	return nil
 }
@@ -0,0 +1,165 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Returns the editor command from $VISUAL or $EDITOR, split into its arguments
+func getEditorCommand() []string {
+	for _, variable := range []string{"VISUAL", "EDITOR"} {
+		fields := strings.Fields(os.Getenv(variable))
+		if len(fields) > 0 {
+			return fields
+		}
+	}
+
+	if runtime.GOOS == "windows" {
+		return []string{"notepad"}
+	}
+
+	return []string{"vi"}
+}
+
+func runEditor(filePath string) error {
+	editor := getEditorCommand()
+
+	cmd := exec.Command(editor[0], append(editor[1:], filePath)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	err := cmd.Run()
+	if err != nil {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("Running the editor '%s' failed: %w.", editor[0], err)
+	}
+
+	return nil
+}
+
+// Opens the configuration in the user's editor. If the edited file can no
+// longer be parsed, the user can edit it again or restore the previous content.
+func editConfiguration(configLocation *string) error {
+	if isRemoteLocation(*configLocation) {
+		return errRemoteConfiguration(*configLocation)
+	}
+
+	filePath := replaceTildePath(*configLocation)
+
+	original, err := os.ReadFile(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("There is no configuration at '%s', you can create one with 'tooli create-config'.", filePath)
+	}
+	if err != nil {
+		return err
+	}
+
+	for {
+		err = runEditor(filePath)
+		if err != nil {
+			return err
+		}
+
+		config, err := loadConfiguration(filePath)
+		if err == nil {
+			problems := getConfigurationProblems(&config)
+			for _, problem := range problems {
+				logWarning("%s.", problem)
+			}
+			if len(problems) == 0 {
+				fmt.Println("The configuration is valid.")
+			}
+
+			return nil
+		}
+
+		if assumeYes {
+			writeErr := os.WriteFile(filePath, original, 0644)
+			if writeErr != nil {
+				return writeErr
+			}
+
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return fmt.Errorf("The configuration can not be parsed, the changes were reverted: %v.", err)
+		}
+
+		fmt.Printf("The configuration can not be parsed: %v.\n", err)
+		fmt.Print("Edit it again? Otherwise the changes are reverted. [Y/n]")
+		var input string
+		fmt.Scanln(&input)
+		if input != "" && (input[0] == 'n' || input[0] == 'N') {
+			err = os.WriteFile(filePath, original, 0644)
+			if err != nil {
+				return err
+			}
+
+			fmt.Println("Reverted the configuration.")
+			return nil
+		}
+	}
+}
+
+// Prints a single value of the configuration file, strings are printed as is
+func printConfigValue(configLocation *string, path string) error {
+	raw, err := readRawConfiguration(*configLocation)
+	if err != nil {
+		return err
+	}
+
+	value, err := getRawValue(raw, path)
+	if err != nil {
+		return err
+	}
+
+	if text, ok := value.(string); ok {
+		fmt.Println(text)
+		return nil
+	}
+
+	bytes, err := json.MarshalIndent(value, "", "\t")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(bytes))
+
+	return nil
+}
+
+// Sets a single value of the configuration file. Values that look like JSON
+// but do not fit the field, like a version '1.2', are stored as strings.
+func setConfigValue(configLocation *string, path string, text string) error {
+	raw, err := readRawConfiguration(*configLocation)
+	if err != nil {
+		return err
+	}
+
+	value := parseRawValue(text)
+	err = setRawValue(raw, path, value)
+	if err != nil {
+		return err
+	}
+
+	if _, isString := value.(string); !isString && checkRawConfiguration(raw) != nil {
+		err = setRawValue(raw, path, text)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = checkRawConfiguration(raw)
+	if err != nil {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("The value does not fit the key '%s': %w.", path, err)
+	}
+
+	return writeRawConfiguration(*configLocation, raw)
+}
@@ -0,0 +1,99 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Replaces a key that is given as a path by the content of the file, so that
+// the key works on other machines as well
+func inlineKeyFile(name string, key string, armorPrefix string, field string) string {
+	if key == "" || strings.HasPrefix(strings.TrimSpace(key), armorPrefix) {
+		return key
+	}
+
+	content, err := os.ReadFile(expandPath(key))
+	if err != nil || !bytes.HasPrefix(bytes.TrimSpace(content), []byte(armorPrefix)) {
+		logWarning("Leaving out '%s' of tool '%s', '%s' can not be included as text.", field, name, key)
+		return ""
+	}
+
+	return string(content)
+}
+
+// Returns a copy of the tool without entries that only make sense on this machine
+func getPortableTool(name string, tool Tool) Tool {
+	if tool.Token != nil {
+		logWarning("Leaving out the 'token' of tool '%s'.", name)
+		tool.Token = nil
+	}
+
+	tool.GpgKey = inlineKeyFile(name, tool.GpgKey, armorPrefix, "gpg_key")
+	tool.CosignPubkey = inlineKeyFile(name, tool.CosignPubkey, "-----BEGIN", "cosign_pubkey")
+
+	return tool
+}
+
+// Prints the given tools (or all tools) as a configuration snippet that can
+// be pasted into another configuration
+func exportTools(configLocation *string, specs []string, format string) error {
+	if format != "json" && format != "toml" {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("Unknown format '%s', expected 'json' or 'toml'.", format)
+	}
+
+	// The snippet is the only output on stdout, messages go to stderr
+	output := os.Stdout
+	os.Stdout = os.Stderr
+	defer func() { os.Stdout = output }()
+
+	config, err := loadConfiguration(*configLocation)
+	if err != nil {
+		return err
+	}
+
+	names, _, err := config.expandToolSpecs(specs)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		names = config.getToolNames()
+	}
+
+	catalog := Catalog{Tools: make(map[string]Tool, len(names))}
+	for _, name := range names {
+		tool, found := config.Tools[name]
+		if !found {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return fmt.Errorf("Tool '%s' not found in configuration.", name)
+		}
+
+		catalog.Tools[name] = getPortableTool(name, tool)
+	}
+
+	var result bytes.Buffer
+	encoder := json.NewEncoder(&result)
+	encoder.SetIndent("", "\t")
+	encoder.SetEscapeHTML(false)
+	err = encoder.Encode(catalog)
+	if err != nil {
+		return err
+	}
+
+	content := result.Bytes()
+	if format == "toml" {
+		content, err = convertToToml(content)
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprint(output, string(content))
+
+	return nil
+}
@@ -13,163 +13,929 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
+	"runtime"
 	"strings"
+
+	"github.com/bodgit/sevenzip"
+	"github.com/ulikunitz/xz"
 )
 
-func getRenameTarget(fullName string, binaries []Binary) string {
-	if strings.HasSuffix(fullName, "/") {
+type AssetType int
+
+const (
+	atTarGz AssetType = iota
+	atTarXz
+	atTar
+	atZip
+	atSevenZip
+	atDeb
+	atGzip
+	atXz
+	atAppImage
+	atRaw
+)
+
+var (
+	gzipMagic     = []byte{0x1f, 0x8b}
+	zipMagic      = []byte("PK\x03\x04")
+	zipEmptyMagic = []byte("PK\x05\x06")
+	debMagic      = []byte("!<arch>\n")
+	rpmMagic      = []byte{0xed, 0xab, 0xee, 0xdb}
+	xzMagic       = []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}
+	sevenZipMagic = []byte{'7', 'z', 0xbc, 0xaf, 0x27, 0x1c}
+	bzip2Magic    = []byte("BZh")
+	pgpMagic      = []byte("-----BEGIN PGP")
+	elfMagic      = []byte("\x7fELF")
+)
+
+// Content types GitHub reports for assets that are never installable binaries
+var unexpectedContentTypes = map[string]string{
+	"application/x-rpm":                    "RPM package",
+	"application/x-redhat-package-manager": "RPM package",
+	"application/pgp-signature":            "signature file",
+	"application/pgp-keys":                 "public key",
+}
+
+var unexpectedSuffixes = map[string]string{
+	".rpm":     "RPM package",
+	".apk":     "Alpine package",
+	".sig":     "signature file",
+	".asc":     "signature file",
+	".minisig": "signature file",
+	".pem":     "certificate",
+	".sbom":    "SBOM",
+	".sha256":  "checksum file",
+	".sha512":  "checksum file",
+}
+
+var sourceArchiveRegex = regexp.MustCompile(`(?i)[-_.](src|source|vendored)(\.|$)`)
+
+func getAssetType(assetName string) AssetType {
+	if strings.HasSuffix(assetName, ".tar.gz") || strings.HasSuffix(assetName, ".tgz") {
+		return atTarGz
+	} else if strings.HasSuffix(assetName, ".tar.xz") || strings.HasSuffix(assetName, ".txz") {
+		return atTarXz
+	} else if strings.HasSuffix(assetName, ".tar") {
+		return atTar
+	} else if strings.HasSuffix(assetName, ".zip") {
+		return atZip
+	} else if strings.HasSuffix(assetName, ".7z") {
+		return atSevenZip
+	} else if strings.HasSuffix(assetName, ".deb") {
+		return atDeb
+	} else if strings.HasSuffix(assetName, ".gz") {
+		return atGzip
+	} else if strings.HasSuffix(assetName, ".xz") {
+		return atXz
+	} else if strings.HasSuffix(strings.ToLower(assetName), ".appimage") {
+		return atAppImage
+	} else {
+		return atRaw
+	}
+}
+
+func describeMagic(rawData []byte) string {
+	switch {
+	case bytes.HasPrefix(rawData, gzipMagic):
+		return "gzip archive"
+	case bytes.HasPrefix(rawData, zipMagic), bytes.HasPrefix(rawData, zipEmptyMagic):
+		return "zip archive"
+	case bytes.HasPrefix(rawData, debMagic):
+		return "Debian package"
+	case bytes.HasPrefix(rawData, rpmMagic):
+		return "RPM package"
+	case bytes.HasPrefix(rawData, xzMagic):
+		return "xz archive"
+	case bytes.HasPrefix(rawData, sevenZipMagic):
+		return "7z archive"
+	case bytes.HasPrefix(rawData, bzip2Magic):
+		return "bzip2 archive"
+	case bytes.HasPrefix(rawData, pgpMagic):
+		return "signature file"
+	default:
 		return ""
 	}
+}
+
+// Verifies that the downloaded asset actually is what its name claims to be,
+// catching configs that accidentally match packages, signatures or source archives
+func validateAsset(data *io.SectionReader, asset *Asset, assetType AssetType) error {
+	lowerName := strings.ToLower(asset.Name)
+	for suffix, kind := range unexpectedSuffixes {
+		if strings.HasSuffix(lowerName, suffix) {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return fmt.Errorf("The matched asset '%s' is a %s, not a binary or archive. Please make the asset name in the config more specific.", asset.Name, kind)
+		}
+	}
+
+	if kind, found := unexpectedContentTypes[strings.ToLower(asset.ContentType)]; found {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("The matched asset '%s' has content type '%s' (%s), not a binary or archive. Please make the asset name in the config more specific.", asset.Name, asset.ContentType, kind)
+	}
+
+	if sourceArchiveRegex.MatchString(strings.TrimSuffix(lowerName, path.Ext(lowerName))) {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("The matched asset '%s' looks like a source archive. Please make the asset name in the config more specific.", asset.Name)
+	}
+
+	// All magics are within the first bytes of the file
+	rawData := make([]byte, 512)
+	count, err := data.ReadAt(rawData, 0)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	rawData = rawData[:count]
+
+	detected := describeMagic(rawData)
+
+	switch assetType {
+	case atTarGz, atGzip:
+		if !bytes.HasPrefix(rawData, gzipMagic) {
+			return assetMismatchError(asset.Name, "gzip archive", detected)
+		}
+	case atTarXz, atXz:
+		if !bytes.HasPrefix(rawData, xzMagic) {
+			return assetMismatchError(asset.Name, "xz archive", detected)
+		}
+	case atZip:
+		if !bytes.HasPrefix(rawData, zipMagic) && !bytes.HasPrefix(rawData, zipEmptyMagic) {
+			return assetMismatchError(asset.Name, "zip archive", detected)
+		}
+	case atSevenZip:
+		if !bytes.HasPrefix(rawData, sevenZipMagic) {
+			return assetMismatchError(asset.Name, "7z archive", detected)
+		}
+	case atDeb:
+		if !bytes.HasPrefix(rawData, debMagic) {
+			return assetMismatchError(asset.Name, "Debian package", detected)
+		}
+	case atTar:
+		// Tar has its magic at offset 257, old formats have none at all
+		if detected != "" {
+			return assetMismatchError(asset.Name, "tar archive", detected)
+		}
+	case atAppImage:
+		// AppImages are ELF files with 'AI' and the AppImage type in the padding of the ELF header
+		if !bytes.HasPrefix(rawData, elfMagic) || len(rawData) < 11 || string(rawData[8:10]) != "AI" {
+			return assetMismatchError(asset.Name, "AppImage", detected)
+		}
+	case atRaw:
+		if detected != "" {
+			return assetMismatchError(asset.Name, "binary", detected)
+		}
+	}
+
+	return nil
+}
+
+func assetMismatchError(assetName string, expected string, detected string) error {
+	if detected == "" {
+		detected = "unknown file type"
+	}
+
+	//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+	return fmt.Errorf("The asset '%s' should be a %s based on its name, but its content is a %s.", assetName, expected, detected)
+}
+
+// Matches a binary name against the path of an archive entry. Names can be
+// glob patterns like 'tool-*' for binaries that contain the version, and can
+// contain directories like 'bin/tool' to select between files of the same name.
+func matchesBinaryName(pattern string, fullName string) bool {
+	parts := strings.Split(path.Clean(fullName), "/")
+	count := strings.Count(pattern, "/") + 1
+	if count > len(parts) {
+		return false
+	}
+
+	candidate := strings.Join(parts[len(parts)-count:], "/")
+	if !strings.ContainsAny(pattern, "*?[") {
+		return pattern == candidate
+	}
+
+	matched, err := path.Match(pattern, candidate)
+	return err == nil && matched
+}
 
-	fileName := path.Base(fullName)
+// Returns the index of the binary matching the archive entry and the name to
+// install it as, or -1 if the entry is not one of the binaries
+func findBinary(fullName string, binaries []Binary) (int, string) {
+	if strings.HasSuffix(fullName, "/") {
+		return -1, ""
+	}
 
-	for _, binary := range binaries {
-		if fileName == binary.Name {
+	for i, binary := range binaries {
+		if matchesBinaryName(binary.Name, fullName) {
 			if binary.RenameTo != "" {
-				return binary.RenameTo
-			} else {
-				return fileName
+				return i, binary.RenameTo
 			}
+			return i, path.Base(fullName)
 		}
 	}
 
-	return ""
+	return -1, ""
+}
+
+// Rejects archive entries that would end up outside of the output directory
+// if extracted with their path, as archives come from third parties
+func validateEntryName(name string) error {
+	cleaned := path.Clean(strings.ReplaceAll(name, "\\", "/"))
+	if path.IsAbs(cleaned) || filepath.VolumeName(name) != "" || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("The archive contains the unsafe path '%s'.", name)
+	}
+
+	return nil
 }
 
-func extractFilesZip(rawData []byte, binaries []Binary, outputPath *string) error {
-	byteReader := bytes.NewReader(rawData)
+// Returns the path to write an installed file to. Names escaping the output
+// directory are refused and symlinks are removed instead of written through.
+func prepareOutputFile(outputPath string, fileName string) (string, error) {
+	filePath := filepath.Join(outputPath, fileName)
 
-	zipReader, err := zip.NewReader(byteReader, int64(len(rawData)))
+	relative, err := filepath.Rel(outputPath, filePath)
+	if err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) || filepath.IsAbs(fileName) {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return "", fmt.Errorf("Refusing to install '%s' outside of the installation directory.", fileName)
+	}
+
+	if info, err := os.Lstat(filePath); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		err = os.Remove(filePath)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	err = moveAsideInUseFile(filePath)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	toExtract := len(binaries)
-	extracted := 0
+	return filePath, nil
+}
 
-	for _, file := range zipReader.File {
-		fileName := getRenameTarget(file.Name, binaries)
-		if fileName == "" {
-			continue
+// A regular file or a link in an archive
+type archiveEntry struct {
+	name string
+	// Path in the archive the entry links to, empty for regular files
+	link    string
+	content io.Reader
+	// Permission bits from the archive, zero if it does not store any
+	mode os.FileMode
+}
+
+// Returns the permissions to extract the entry with, binaries are always executable
+func (entry *archiveEntry) getMode(isBinary bool) os.FileMode {
+	mode := entry.mode.Perm()
+	if mode == 0 {
+		mode = 0644
+	}
+
+	if isBinary {
+		mode |= 0111
+	}
+
+	return mode
+}
+
+// Calls visit for every regular file and link in an archive, until visit
+// returns errStopWalk. Can be called repeatedly.
+type archiveWalker func(visit func(entry archiveEntry) error) error
+
+var errStopWalk = errors.New("stop walking the archive")
+
+// Resolves the target of a symlink relative to its directory, hard links in
+// tar archives are already relative to the root of the archive
+func resolveLink(name string, target string, isSymlink bool) (string, error) {
+	if isSymlink {
+		if path.IsAbs(target) {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return "", fmt.Errorf("The archive contains the unsafe link '%s' to '%s'.", name, target)
 		}
+		target = path.Join(path.Dir(name), target)
+	}
 
-		fileReader, err := file.Open()
-		if err != nil {
-			return err
+	err := validateEntryName(target)
+	if err != nil {
+		return "", err
+	}
+
+	return path.Clean(target), nil
+}
+
+func walkZip(data *io.SectionReader) (archiveWalker, error) {
+	zipReader, err := zip.NewReader(data, data.Size())
+	if err != nil {
+		return nil, err
+	}
+
+	return func(visit func(archiveEntry) error) error {
+		for _, file := range zipReader.File {
+			mode := file.Mode()
+			if !mode.IsRegular() && mode&os.ModeSymlink == 0 {
+				continue
+			}
+
+			fileReader, err := file.Open()
+			if err != nil {
+				return err
+			}
+
+			entry := archiveEntry{name: file.Name, content: fileReader, mode: mode}
+			// The content of a symlink is its target
+			if mode&os.ModeSymlink != 0 {
+				target, err := io.ReadAll(io.LimitReader(fileReader, 4096))
+				if err == nil {
+					entry.link, err = resolveLink(file.Name, string(target), true)
+				}
+				if err != nil {
+					fileReader.Close()
+					return err
+				}
+			}
+
+			err = visit(entry)
+			fileReader.Close()
+			if err != nil {
+				return err
+			}
 		}
-		defer fileReader.Close()
 
-		fileContent, err := io.ReadAll(fileReader)
-		if err != nil {
-			return err
+		return nil
+	}, nil
+}
+
+func walkSevenZip(data *io.SectionReader) (archiveWalker, error) {
+	archive, err := sevenzip.NewReader(data, data.Size())
+	if err != nil {
+		return nil, err
+	}
+
+	return func(visit func(archiveEntry) error) error {
+		for _, file := range archive.File {
+			if !file.Mode().IsRegular() {
+				continue
+			}
+
+			fileReader, err := file.Open()
+			if err != nil {
+				return err
+			}
+
+			err = visit(archiveEntry{name: file.Name, content: fileReader, mode: file.Mode()})
+			fileReader.Close()
+			if err != nil {
+				return err
+			}
 		}
 
-		filePath := filepath.Join(*outputPath, fileName)
+		return nil
+	}, nil
+}
 
-		err = os.WriteFile(filePath, fileContent, 0755)
+func walkTar(open func() (io.ReadCloser, error)) archiveWalker {
+	return func(visit func(archiveEntry) error) error {
+		reader, err := open()
 		if err != nil {
 			return err
 		}
+		defer reader.Close()
+		tarReader := tar.NewReader(reader)
 
-		extracted++
-		if extracted == toExtract {
-			break
+		for {
+			header, err := tarReader.Next()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			entry := archiveEntry{name: header.Name, content: tarReader, mode: os.FileMode(header.Mode)}
+			switch header.Typeflag {
+			case tar.TypeReg:
+			case tar.TypeSymlink, tar.TypeLink:
+				entry.link, err = resolveLink(header.Name, header.Linkname, header.Typeflag == tar.TypeSymlink)
+				if err != nil {
+					return err
+				}
+			default:
+				// Directories and special files are never installed
+				continue
+			}
+
+			err = visit(entry)
+			if err != nil {
+				return err
+			}
 		}
 	}
+}
 
-	return nil
+// Returns a new reader from the start of the data, independent of other readers
+func readFromStart(data *io.SectionReader) io.Reader {
+	return io.NewSectionReader(data, 0, data.Size())
 }
 
-func extractFilesTarGz(rawData []byte, binaries []Binary, outputPath *string) error {
-	byteReader := bytes.NewReader(rawData)
+func walkTarGz(data *io.SectionReader) (archiveWalker, error) {
+	_, err := gzip.NewReader(readFromStart(data))
+	if err != nil {
+		return nil, err
+	}
+
+	return walkTar(func() (io.ReadCloser, error) {
+		return gzip.NewReader(readFromStart(data))
+	}), nil
+}
 
-	gzipReader, err := gzip.NewReader(byteReader)
+func walkTarXz(data *io.SectionReader) (archiveWalker, error) {
+	_, err := xz.NewReader(readFromStart(data))
 	if err != nil {
+		return nil, err
+	}
+
+	return walkTar(func() (io.ReadCloser, error) {
+		reader, err := xz.NewReader(readFromStart(data))
+		return io.NopCloser(reader), err
+	}), nil
+}
+
+func walkPlainTar(data *io.SectionReader) archiveWalker {
+	return walkTar(func() (io.ReadCloser, error) {
+		return io.NopCloser(readFromStart(data)), nil
+	})
+}
+
+// Replaces linkPath with a symlink to target. Creating symlinks needs special
+// privileges on Windows, so the file is copied if that fails.
+func linkFile(target string, linkPath string) error {
+	err := os.Remove(linkPath)
+	if err != nil && !os.IsNotExist(err) {
 		return err
 	}
-	defer gzipReader.Close()
 
-	tarReader := tar.NewReader(gzipReader)
+	err = os.Symlink(target, linkPath)
+	if err != nil && runtime.GOOS == "windows" {
+		return copyFile(target, linkPath, 0755)
+	}
 
-	toExtract := len(binaries)
-	extracted := 0
+	return err
+}
 
-	for {
-		header, err := tarReader.Next()
-		if err == io.EOF {
-			break
+// Extracts the whole archive into the tool's own directory and links the
+// binaries into the output directory, for tools that need files next to their
+// binaries. Returns the links relative to the output directory and the
+// extracted files as absolute paths.
+func extractDirectory(walk archiveWalker, name string, binaries []Binary, auxiliary []AuxiliaryFile, outputPath *string) ([]string, error) {
+	toolDirectory, err := getToolDirectory(name)
+	if err != nil {
+		return nil, err
+	}
+
+	err = removeDirectoryInUse(toolDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	var extracted []string
+	linkTargets := make(map[int]string)
+	links := make(map[int]string)
+	doneAuxiliary := make(map[int]bool)
+	// Links in the archive are created once all files they could point to exist
+	archiveLinks := make(map[string]string)
+	var listing []string
+
+	err = walk(func(entry archiveEntry) error {
+		err := validateEntryName(entry.name)
+		if err != nil {
+			return err
 		}
+
+		filePath, err := prepareOutputFile(toolDirectory, path.Clean(strings.ReplaceAll(entry.name, "\\", "/")))
 		if err != nil {
 			return err
 		}
 
-		fileName := getRenameTarget(header.Name, binaries)
-		if fileName == "" {
+		listing = append(listing, entry.name)
+
+		index, target := findBinary(entry.name, binaries)
+		isBinary := index >= 0 && linkTargets[index] == ""
+
+		if entry.link != "" {
+			archiveLinks[filePath] = filepath.Join(toolDirectory, filepath.FromSlash(entry.link))
+		} else {
+			logVerbose("Writing '%s'.", filePath)
+			err = writeFile(filePath, entry.content, entry.getMode(isBinary))
+			if err != nil {
+				return err
+			}
+		}
+		extracted = append(extracted, filePath)
+
+		if isBinary {
+			linkTargets[index] = filePath
+			links[index] = target
+		} else if index, directory, target := findAuxiliaryFile(entry.name, auxiliary); index >= 0 && !doneAuxiliary[index] && entry.link == "" {
+			doneAuxiliary[index] = !auxiliary[index].multiple
+
+			auxiliaryPath, err := prepareOutputFile(directory, target)
+			if err == nil {
+				err = copyFile(filePath, auxiliaryPath, 0644)
+			}
+			if err != nil {
+				return err
+			}
+			extracted = append(extracted, auxiliaryPath)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[int]bool)
+	for index := range linkTargets {
+		found[index] = true
+	}
+	err = getMissingBinariesError(binaries, found, listing)
+	if err != nil {
+		return nil, err
+	}
+
+	for linkPath, target := range archiveLinks {
+		err = linkFile(target, linkPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range binaries {
+		if linkTargets[i] == "" {
 			continue
 		}
 
-		filePath := filepath.Join(*outputPath, fileName)
+		// Binaries that are links in the archive were extracted with the
+		// mode of the file they point to
+		if info, err := os.Stat(linkTargets[i]); err == nil && info.Mode()&0111 == 0 {
+			os.Chmod(linkTargets[i], info.Mode().Perm()|0111)
+		}
 
-		file, err := os.Create(filePath)
+		linkPath, err := prepareOutputFile(*outputPath, links[i])
 		if err != nil {
-			return err
+			return nil, err
 		}
-		defer file.Close()
 
-		_, err = io.Copy(file, tarReader)
+		err = linkFile(linkTargets[i], linkPath)
+		if err != nil {
+			return nil, err
+		}
+		extracted = append(extracted, links[i])
+	}
+
+	return extracted, nil
+}
+
+// Extracts the tool's binaries into the output directory and its auxiliary
+// files, e.g. shell completions, into their own directories. Binaries are
+// returned relative to the output directory, auxiliary files as absolute paths.
+func extractArchive(walk archiveWalker, binaries []Binary, auxiliary []AuxiliaryFile, outputPath *string) ([]string, error) {
+	var extracted []string
+	doneBinaries := make(map[int]bool)
+	doneAuxiliary := make(map[int]bool)
+	links := make(map[string]string)
+	linkedBinaries := make(map[int]string)
+	var listing []string
+
+	install := func(directory string, target string, content io.Reader, mode os.FileMode) (string, error) {
+		filePath, err := prepareOutputFile(directory, target)
+		if err != nil {
+			return "", err
+		}
+
+		logVerbose("Writing '%s'.", filePath)
+		return filePath, writeFile(filePath, content, mode)
+	}
+
+	err := walk(func(entry archiveEntry) error {
+		err := validateEntryName(entry.name)
 		if err != nil {
 			return err
 		}
 
-		os.Chmod(filePath, 0755)
+		listing = append(listing, entry.name)
+
+		if entry.link != "" {
+			links[path.Clean(entry.name)] = entry.link
+			if index, _ := findBinary(entry.name, binaries); index >= 0 {
+				linkedBinaries[index] = entry.name
+			}
+			return nil
+		}
+
+		if index, target := findBinary(entry.name, binaries); index >= 0 && !doneBinaries[index] {
+			_, err = install(*outputPath, target, entry.content, entry.getMode(true))
+			if err != nil {
+				return err
+			}
+			extracted = append(extracted, target)
+			doneBinaries[index] = true
+		} else if index, directory, target := findAuxiliaryFile(entry.name, auxiliary); index >= 0 && !doneAuxiliary[index] {
+			filePath, err := install(directory, target, entry.content, 0644)
+			if err != nil {
+				return err
+			}
+			extracted = append(extracted, filePath)
+			doneAuxiliary[index] = !auxiliary[index].multiple
+		} else {
+			return nil
+		}
+
+		if len(doneBinaries) == len(binaries) && len(doneAuxiliary) == countSingleFiles(auxiliary) {
+			return errStopWalk
+		}
+		return nil
+	})
+	if err != nil && err != errStopWalk {
+		return nil, err
+	}
+
+	// Binaries that are links, e.g. to a versioned file, are installed from
+	// the file the link resolves to, which needs another pass over the archive
+	linkTargets := make(map[string][]int)
+	for index, linkName := range linkedBinaries {
+		if !doneBinaries[index] {
+			resolved := resolveLinkChain(links, linkName)
+			linkTargets[resolved] = append(linkTargets[resolved], index)
+		}
+	}
+
+	if len(linkTargets) == 0 {
+		return extracted, getMissingBinariesError(binaries, doneBinaries, listing)
+	}
+
+	err = walk(func(entry archiveEntry) error {
+		indices := linkTargets[path.Clean(entry.name)]
+		if entry.link != "" || len(indices) == 0 {
+			return nil
+		}
+
+		// Several binaries can link to the same file, which is copied for all but the first
+		var firstPath string
+		for _, index := range indices {
+			var err error
+			_, target := findBinary(linkedBinaries[index], binaries)
+			if firstPath == "" {
+				firstPath, err = install(*outputPath, target, entry.content, entry.getMode(true))
+			} else {
+				var filePath string
+				filePath, err = prepareOutputFile(*outputPath, target)
+				if err == nil {
+					err = copyFile(firstPath, filePath, entry.getMode(true))
+				}
+			}
+			if err != nil {
+				return err
+			}
+			extracted = append(extracted, target)
+			doneBinaries[index] = true
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return extracted, getMissingBinariesError(binaries, doneBinaries, listing)
+}
+
+// Lists the binaries that were not found together with the files in the
+// archive, so that the configured names can be fixed
+func getMissingBinariesError(binaries []Binary, found map[int]bool, listing []string) error {
+	var missing []string
+	for i, binary := range binaries {
+		if !found[i] {
+			missing = append(missing, fmt.Sprintf("'%s'", binary.Name))
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	const maxListed = 50
+	files := listing
+	if len(files) > maxListed {
+		files = append(files[:maxListed:maxListed], fmt.Sprintf("... and %d more", len(listing)-maxListed))
+	}
 
-		extracted++
-		if extracted == toExtract {
+	//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+	return fmt.Errorf("The archive does not contain the binaries %s. It contains these files:\n  %s", strings.Join(missing, ", "), strings.Join(files, "\n  "))
+}
+
+// Follows links within the archive, giving up on cycles
+func resolveLinkChain(links map[string]string, name string) string {
+	result := path.Clean(name)
+	for i := 0; i < 16; i++ {
+		target, found := links[result]
+		if !found {
 			break
 		}
+		result = target
 	}
 
-	return nil
+	return result
 }
 
-func extractFilesRaw(rawData []byte, binaries []Binary, outputPath *string) error {
+func extractFilesRaw(reader io.Reader, binaries []Binary, outputPath *string) ([]string, error) {
 	if len(binaries) != 1 {
 		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
-		return errors.New("Invalid number of binaries provided. Non-archive type assets can only be one binary.")
+		return nil, errors.New("Invalid number of binaries provided. Non-archive type assets can only be one binary.")
 	}
 
-	fileName := binaries[0].Name
+	fileName := path.Base(binaries[0].Name)
 	if binaries[0].RenameTo != "" {
 		fileName = binaries[0].RenameTo
 	}
 
-	filePath := filepath.Join(*outputPath, fileName)
+	filePath, err := prepareOutputFile(*outputPath, fileName)
+	if err != nil {
+		return nil, err
+	}
 
+	logVerbose("Writing '%s'.", filePath)
 	file, err := os.Create(filePath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer file.Close()
 
-	byteReader := bytes.NewReader(rawData)
-
-	_, err = io.Copy(file, byteReader)
+	_, err = io.Copy(file, reader)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	os.Chmod(filePath, 0755)
 
-	return nil
+	return []string{fileName}, nil
 }
 
-func extractFiles(rawData []byte, asset *Asset, tool *Tool, outputPath *string) error {
-	if strings.HasSuffix(asset.Name, ".tar.gz") {
-		return extractFilesTarGz(rawData, tool.Binaries, outputPath)
-	} else if strings.HasSuffix(asset.Name, ".zip") {
-		return extractFilesZip(rawData, tool.Binaries, outputPath)
+// Installs assets that are a single compressed binary, e.g. 'tool-linux-amd64.gz'
+func extractFilesCompressed(data *io.SectionReader, assetType AssetType, binaries []Binary, outputPath *string) ([]string, error) {
+	var reader io.Reader
+	var err error
+	if assetType == atGzip {
+		reader, err = gzip.NewReader(readFromStart(data))
 	} else {
-		fmt.Println("WARNING: The asset does not have a file ending. While this can be legitimate, you should probably talk to the tool author to see if he is willing to change that.")
-		return extractFilesRaw(rawData, tool.Binaries, outputPath)
+		reader, err = xz.NewReader(readFromStart(data))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return extractFilesRaw(reader, binaries, outputPath)
+}
+
+func isArchive(assetType AssetType) bool {
+	switch assetType {
+	case atTarGz, atTarXz, atTar, atZip, atSevenZip, atDeb:
+		return true
+	default:
+		return false
+	}
+}
+
+// Returns nil for assets that are not archives
+func getArchiveWalker(data *io.SectionReader, assetType AssetType) (archiveWalker, error) {
+	switch assetType {
+	case atTarGz:
+		return walkTarGz(data)
+	case atTarXz:
+		return walkTarXz(data)
+	case atTar:
+		return walkPlainTar(data), nil
+	case atZip:
+		return walkZip(data)
+	case atSevenZip:
+		return walkSevenZip(data)
+	case atDeb:
+		return walkDeb(data)
+	default:
+		return nil, nil
+	}
+}
+
+// Some releases wrap the actual archive in another one, e.g. a tar.gz in a zip.
+// If none of the binaries are in the archive, but it contains exactly one other
+// archive, that one is extracted instead. Only a single level is unwrapped.
+// The inner archive is stored in a temporary file, which the caller removes.
+func unwrapNestedArchive(walk archiveWalker, binaries []Binary) (archiveWalker, *os.File, error) {
+	hasBinary := false
+	var nestedName string
+	var nestedFile *os.File
+	var nestedData *io.SectionReader
+	nestedCount := 0
+
+	err := walk(func(entry archiveEntry) error {
+		if entry.link != "" {
+			return nil
+		}
+
+		if index, _ := findBinary(entry.name, binaries); index >= 0 {
+			hasBinary = true
+			return errStopWalk
+		}
+
+		if isArchive(getAssetType(path.Base(entry.name))) {
+			nestedCount++
+			if nestedCount == 1 {
+				var err error
+				nestedName = entry.name
+				nestedFile, nestedData, err = spoolToTempFile(entry.content)
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil && err != errStopWalk || hasBinary || nestedCount != 1 {
+		if nestedFile != nil {
+			removeTempFile(nestedFile)
+		}
+		if err != nil && err != errStopWalk {
+			return nil, nil, err
+		}
+		return walk, nil, nil
+	}
+
+	logInfo("Extracting the nested archive '%s'.", nestedName)
+	nestedWalk, err := getArchiveWalker(nestedData, getAssetType(path.Base(nestedName)))
+	if err != nil {
+		removeTempFile(nestedFile)
+		return nil, nil, err
+	}
+
+	return nestedWalk, nestedFile, nil
+}
+
+// Copies the reader into a temporary file, so that large assets need not be
+// held in memory. The caller removes the file with removeTempFile.
+func spoolToTempFile(reader io.Reader) (*os.File, *io.SectionReader, error) {
+	file, err := os.CreateTemp("", "tooli-*")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	size, err := io.Copy(file, reader)
+	if err != nil {
+		removeTempFile(file)
+		return nil, nil, err
+	}
+
+	return file, io.NewSectionReader(file, 0, size), nil
+}
+
+func removeTempFile(file *os.File) {
+	file.Close()
+	os.Remove(file.Name())
+}
+
+// Extracts the tool's binaries from the asset and returns the names of the
+// installed files
+func extractFiles(data *io.SectionReader, asset *Asset, name string, tool *Tool, config *Configuration, outputPath *string) ([]string, error) {
+	assetType := getAssetType(asset.Name)
+
+	err := validateAsset(data, asset, assetType)
+	if err != nil {
+		return nil, err
+	}
+
+	walk, err := getArchiveWalker(data, assetType)
+	if err == nil && walk != nil {
+		var nestedFile *os.File
+		walk, nestedFile, err = unwrapNestedArchive(walk, tool.Binaries)
+		if nestedFile != nil {
+			defer removeTempFile(nestedFile)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if walk != nil && tool.InstallMode == installModeDirectory {
+		return extractDirectory(walk, name, tool.Binaries, getAuxiliaryFiles(tool, config), outputPath)
+	}
+	if walk != nil {
+		return extractArchive(walk, tool.Binaries, getAuxiliaryFiles(tool, config), outputPath)
+	}
+
+	switch assetType {
+	case atGzip, atXz:
+		return extractFilesCompressed(data, assetType, tool.Binaries, outputPath)
+	case atAppImage:
+		return extractFilesRaw(readFromStart(data), tool.Binaries, outputPath)
+	default:
+		logWarning("The asset does not have a file ending. While this can be legitimate, you should probably talk to the tool author to see if he is willing to change that.")
+		return extractFilesRaw(readFromStart(data), tool.Binaries, outputPath)
 	}
 }
@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+type GistFile struct {
+	Filename string `json:"filename"`
+	Type     string `json:"type"`
+	RawUrl   string `json:"raw_url"`
+	Size     int64  `json:"size"`
+}
+
+type GistRevision struct {
+	Version     string `json:"version"`
+	CommittedAt string `json:"committed_at"`
+}
+
+type Gist struct {
+	Id          string              `json:"id"`
+	Description string              `json:"description"`
+	Files       map[string]GistFile `json:"files"`
+	History     []GistRevision      `json:"history"`
+	UpdatedAt   string              `json:"updated_at"`
+}
+
+// Source for single-file tools and scripts hosted as GitHub gists. The
+// revision hash of the gist is used as the version.
+type GistSource struct {
+	client   *Downloader
+	id       string
+	revision string
+	token    string
+}
+
+func (source *GistSource) getGistUrl() string {
+	return fmt.Sprintf("%s/gists/%s", githubApiUrl, source.id)
+}
+
+func (source *GistSource) ListReleases(limit int) ([]Release, error) {
+	var gist Gist
+
+	err := source.client.downloadJson(source.getGistUrl(), source.token, &gist)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Release, 0, len(gist.History))
+	for _, revision := range gist.History {
+		result = append(result, Release{TagName: revision.Version, PublishedAt: revision.CommittedAt})
+	}
+
+	return limitReleases(result, limit), nil
+}
+
+func (source *GistSource) GetLatest() (Release, error) {
+	return source.GetByTag(source.revision)
+}
+
+// Returns the given revision of the gist, or the newest if it is empty
+func (source *GistSource) GetByTag(revision string) (Release, error) {
+	var result Release
+	var gist Gist
+
+	gistUrl := source.getGistUrl()
+	if revision != "" {
+		gistUrl += "/" + revision
+	}
+
+	err := source.client.downloadJson(gistUrl, source.token, &gist)
+	if err != nil {
+		return result, err
+	}
+
+	if len(gist.History) == 0 {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return result, fmt.Errorf("The gist '%s' has no revisions.", source.id)
+	}
+
+	result.TagName = gist.History[0].Version
+	result.Name = gist.Description
+	result.PublishedAt = gist.History[0].CommittedAt
+
+	names := make([]string, 0, len(gist.Files))
+	for name := range gist.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		file := gist.Files[name]
+		result.Assets = append(result.Assets, Asset{Name: file.Filename, ContentType: file.Type, Size: file.Size, BrowserDownloadUrl: file.RawUrl})
+	}
+
+	return result, nil
+}
+
+func (source *GistSource) OpenAsset(asset *Asset, offset int64) (io.ReadCloser, error) {
+	// Raw gist URLs contain the revision and need no authentication
+	return source.client.openAsset(asset.BrowserDownloadUrl, "", offset)
+}
+
+func (source *GistSource) DownloadSourceArchive(release *Release, file *os.File) error {
+	return errNoSourceArchive("gists")
+}
@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Source for Gitea-compatible forges like Codeberg or Forgejo
+type GiteaSource struct {
+	client     *Downloader
+	host       string
+	owner      string
+	repository string
+	token      string
+}
+
+func (source *GiteaSource) getRepositoryUrl() string {
+	return fmt.Sprintf("https://%s/api/v1/repos/%s/%s", strings.TrimSuffix(source.host, "/"), source.owner, source.repository)
+}
+
+func (source *GiteaSource) ListReleases(limit int) ([]Release, error) {
+	var result []Release
+
+	// Gitea caps the page size at a server-defined maximum, usually 50
+	perPage := min(limit, 50)
+	for page := 1; len(result) < limit; page++ {
+		var releases []Release
+
+		err := source.client.downloadJson(fmt.Sprintf("%s/releases?limit=%d&page=%d", source.getRepositoryUrl(), perPage, page), source.token, &releases)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, releases...)
+		if len(releases) < perPage {
+			break
+		}
+	}
+
+	return limitReleases(result, limit), nil
+}
+
+func (source *GiteaSource) GetLatest() (Release, error) {
+	var result Release
+
+	err := source.client.downloadJson(source.getRepositoryUrl()+"/releases/latest", source.token, &result)
+
+	return result, err
+}
+
+func (source *GiteaSource) GetByTag(tag string) (Release, error) {
+	var result Release
+
+	err := source.client.downloadJson(source.getRepositoryUrl()+"/releases/tags/"+url.PathEscape(tag), source.token, &result)
+
+	return result, err
+}
+
+func (source *GiteaSource) OpenAsset(asset *Asset, offset int64) (io.ReadCloser, error) {
+	return source.client.openAsset(asset.BrowserDownloadUrl, source.token, offset)
+}
+
+func (source *GiteaSource) DownloadSourceArchive(release *Release, file *os.File) error {
+	return source.client.downloadAssetToFile(release.TarballUrl, source.token, file)
+}
@@ -0,0 +1,108 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+const githubHost = "github.com"
+const githubApiHost = "api.github.com"
+const githubApiUrl = "https://" + githubApiHost
+
+type GithubSource struct {
+	client     *Downloader
+	owner      string
+	repository string
+	token      string
+}
+
+func isGithubHost(host string) bool {
+	return host == "" || host == githubHost
+}
+
+func (source *GithubSource) getRepositoryUrl() string {
+	return fmt.Sprintf("%s/repos/%s/%s", githubApiUrl, source.owner, source.repository)
+}
+
+func (source *GithubSource) ListReleases(limit int) ([]Release, error) {
+	var result []Release
+
+	perPage := min(limit, 100)
+	for page := 1; len(result) < limit; page++ {
+		var releases []Release
+
+		err := source.client.downloadJson(fmt.Sprintf("%s/releases?per_page=%d&page=%d", source.getRepositoryUrl(), perPage, page), source.token, &releases)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, releases...)
+		if len(releases) < perPage {
+			break
+		}
+	}
+
+	return limitReleases(result, limit), nil
+}
+
+func (source *GithubSource) GetLatest() (Release, error) {
+	var result Release
+
+	err := source.client.downloadJson(source.getRepositoryUrl()+"/releases/latest", source.token, &result)
+
+	return result, err
+}
+
+func (source *GithubSource) GetByTag(tag string) (Release, error) {
+	var result Release
+
+	err := source.client.downloadJson(source.getRepositoryUrl()+"/releases/tags/"+url.PathEscape(tag), source.token, &result)
+
+	return result, err
+}
+
+func (source *GithubSource) OpenAsset(asset *Asset, offset int64) (io.ReadCloser, error) {
+	result, err := source.client.openAsset(fmt.Sprintf("%s/releases/assets/%d", source.getRepositoryUrl(), asset.Id), source.token, offset)
+	if err == nil || asset.BrowserDownloadUrl == "" || !source.canUseDownloadUrl(err) {
+		return result, err
+	}
+
+	// The public download URL is served by GitHub's CDN and does not count
+	// against the API rate limit, so it usually works when the API does not
+	logInfo("Downloading '%s' through the API failed, retrying via its download URL.", asset.Name)
+
+	return source.client.openAsset(asset.BrowserDownloadUrl, "", offset)
+}
+
+// Whether the asset may still be downloaded from its public download URL after
+// the API failed: not after an interrupt, and not for errors like 401 or 404,
+// which the tokenless download URL cannot fix, e.g. for private repositories
+func (source *GithubSource) canUseDownloadUrl(err error) bool {
+	if source.client.isInterrupted() || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var rateLimitError *RateLimitError
+	if errors.As(err, &rateLimitError) {
+		return true
+	}
+
+	var statusError *StatusError
+	if errors.As(err, &statusError) {
+		return statusError.StatusCode >= 500 || statusError.StatusCode == http.StatusTooManyRequests
+	}
+
+	// Transport errors like timeouts or refused connections
+	return true
+}
+
+func (source *GithubSource) DownloadSourceArchive(release *Release, file *os.File) error {
+	return source.client.downloadAssetToFile(release.TarballUrl, source.token, file)
+}
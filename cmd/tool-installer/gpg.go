@@ -0,0 +1,121 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+)
+
+const armorPrefix = "-----BEGIN PGP"
+
+// Reads the keys given either inline as armored text or as the path to an
+// armored or binary keyring
+func readGpgKeyRing(key string) (openpgp.EntityList, error) {
+	content := []byte(key)
+	if !strings.HasPrefix(strings.TrimSpace(key), armorPrefix) {
+		var err error
+		content, err = os.ReadFile(expandPath(key))
+		if err != nil {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return nil, fmt.Errorf("Could not read GPG keyring '%s': %v", key, err)
+		}
+	}
+
+	var result openpgp.EntityList
+	var err error
+	if bytes.HasPrefix(bytes.TrimSpace(content), []byte(armorPrefix)) {
+		result, err = openpgp.ReadArmoredKeyRing(bytes.NewReader(content))
+	} else {
+		result, err = openpgp.ReadKeyRing(bytes.NewReader(content))
+	}
+	if err != nil {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return nil, fmt.Errorf("Could not parse GPG key: %v", err)
+	}
+
+	return result, nil
+}
+
+func verifyGpgSignature(keyRing openpgp.EntityList, data io.Reader, signature []byte) error {
+	var err error
+	if bytes.HasPrefix(bytes.TrimSpace(signature), []byte(armorPrefix)) {
+		_, err = openpgp.CheckArmoredDetachedSignature(keyRing, data, bytes.NewReader(signature), nil)
+	} else {
+		_, err = openpgp.CheckDetachedSignature(keyRing, data, bytes.NewReader(signature), nil)
+	}
+
+	return err
+}
+
+func findSignatureAsset(release *Release, name string) (Asset, bool) {
+	for _, suffix := range []string{".asc", ".sig", ".gpg"} {
+		for _, a := range release.Assets {
+			if a.Name == name+suffix {
+				return a, true
+			}
+		}
+	}
+
+	return Asset{}, false
+}
+
+// Verifies the GPG signature of the asset. If only the release's checksum file
+// is signed, its signature is verified and the asset is compared to the digest
+// listed in it instead.
+func verifyGpgAsset(source Source, release *Release, asset *Asset, data *io.SectionReader, digest string, key string) error {
+	keyRing, err := readGpgKeyRing(key)
+	if err != nil {
+		return err
+	}
+
+	signed, signedData := *asset, readFromStart(data)
+	var checksums []byte
+	signatureAsset, found := findSignatureAsset(release, asset.Name)
+	if !found {
+		checksumAsset, hasChecksums := findChecksumAsset(release, asset)
+		if hasChecksums {
+			signatureAsset, found = findSignatureAsset(release, checksumAsset.Name)
+		}
+		if !found {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return fmt.Errorf("A GPG key is configured, but the release contains no signature for '%s'.", asset.Name)
+		}
+
+		signed = checksumAsset
+		checksums, err = readAsset(source, &checksumAsset)
+		if err != nil {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return fmt.Errorf("Could not download '%s': %v", checksumAsset.Name, err)
+		}
+		signedData = bytes.NewReader(checksums)
+	}
+
+	signature, err := readAsset(source, &signatureAsset)
+	if err != nil {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("Could not download the signature '%s': %v", signatureAsset.Name, err)
+	}
+
+	err = verifyGpgSignature(keyRing, signedData, signature)
+	if err != nil {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("Could not verify '%s' with '%s': %v", signed.Name, signatureAsset.Name, err)
+	}
+
+	if signed.Name != asset.Name {
+		expected, found := parseChecksums(string(checksums), asset.Name)
+		if !found || expected != digest {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return fmt.Errorf("The signed '%s' does not list the SHA-256 digest %s for '%s'.", signed.Name, digest, asset.Name)
+		}
+	}
+
+	logInfo("Verified the GPG signature of '%s'.", signed.Name)
+	return nil
+}
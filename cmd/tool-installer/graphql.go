@@ -0,0 +1,118 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const githubGraphqlUrl = githubApiUrl + "/graphql"
+
+// How many repositories are queried in a single GraphQL request
+const graphqlBatchSize = 50
+
+type GraphqlResponse struct {
+	Data map[string]*struct {
+		LatestRelease *struct {
+			TagName string `json:"tagName"`
+		} `json:"latestRelease"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func getRepositoryKey(owner string, repository string) string {
+	return strings.ToLower(owner + "/" + repository)
+}
+
+// Whether the tool installs the latest release of a GitHub repository, which
+// can be fetched together with other tools
+func canBatchLatestRelease(tool *Tool) bool {
+	return isGithubHost(tool.Host) && tool.UrlTemplate == "" && tool.OciImage == "" && tool.Gist == "" &&
+		tool.Version == "" && tool.VersionConstraint == "" && !tool.allowsPrerelease() && len(tool.IgnoreVersions) == 0
+}
+
+// Fetches the tags of the latest releases of the given tools from GitHub's
+// GraphQL API, a few requests for all tools instead of one per tool. GraphQL
+// requires a token, so tools without one are left to the usual requests, as
+// are tools that fail here.
+func (client *Downloader) prefetchLatestTags(config *Configuration, names []string) {
+	if offlineMode {
+		return
+	}
+
+	// Tools with their own token are queried separately
+	batches := make(map[string][]*Tool)
+	for _, name := range names {
+		tool, found := config.Tools[name]
+		if !found || !canBatchLatestRelease(&tool) {
+			continue
+		}
+
+		token, err := client.getToken(&tool)
+		if err != nil || token == "" {
+			continue
+		}
+		batches[token] = append(batches[token], &tool)
+	}
+
+	for token, tools := range batches {
+		for start := 0; start < len(tools); start += graphqlBatchSize {
+			err := client.queryLatestTags(token, tools[start:min(start+graphqlBatchSize, len(tools))])
+			if err != nil {
+				logWarning("Could not fetch the latest releases in bulk, fetching them one by one: %v", err)
+				return
+			}
+		}
+	}
+}
+
+func (client *Downloader) queryLatestTags(token string, tools []*Tool) error {
+	var query strings.Builder
+	query.WriteString("query {")
+	for i, tool := range tools {
+		fmt.Fprintf(&query, " r%d: repository(owner: %s, name: %s) { latestRelease { tagName } }", i, strconv.Quote(tool.Owner), strconv.Quote(tool.Repository))
+	}
+	query.WriteString(" }")
+
+	var response GraphqlResponse
+	err := client.postJson(githubGraphqlUrl, token, map[string]string{"query": query.String()}, &response)
+	if err != nil {
+		return err
+	}
+
+	// Errors for single repositories, e.g. ones that do not exist, come with
+	// data for the others
+	if response.Data == nil && len(response.Errors) > 0 {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("%s", response.Errors[0].Message)
+	}
+
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	for i, tool := range tools {
+		repository := response.Data["r"+strconv.Itoa(i)]
+		if repository != nil && repository.LatestRelease != nil && repository.LatestRelease.TagName != "" {
+			client.latestTags[getRepositoryKey(tool.Owner, tool.Repository)] = repository.LatestRelease.TagName
+		}
+	}
+
+	return nil
+}
+
+// Returns the tag of the tool's latest release if it was fetched in bulk
+func (client *Downloader) getPrefetchedTag(tool *Tool) (string, bool) {
+	if !canBatchLatestRelease(tool) {
+		return "", false
+	}
+
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	tag, found := client.latestTags[getRepositoryKey(tool.Owner, tool.Repository)]
+	return tag, found
+}
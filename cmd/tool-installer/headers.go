@@ -0,0 +1,112 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Returns a copy of the downloader that also sends the given headers with
+// the requests to the host, used for the headers of a single tool
+func (client *Downloader) withHeaders(host string, headers map[string]string) *Downloader {
+	result := *client
+	result.toolHeaders = headers
+	result.toolHeadersHost = host
+	result.client.CheckRedirect = result.checkRedirect
+
+	return &result
+}
+
+// Returns the host that the headers of a tool are sent to, i.e. the host of
+// its API or its downloads. Mirrors, the GitHub download CDN or the
+// 'version_url' of another host do not get them.
+func getToolHeadersHost(tool *Tool) string {
+	host := getToolHost(tool)
+	if host == githubHost {
+		return githubApiHost
+	}
+
+	return host
+}
+
+// Sets the configured headers of the request's host and then those of the
+// tool, so that they can replace the default headers, e.g. 'Authorization'.
+// Environment variables in the values are expanded, so that secrets do not
+// have to be written into the configuration.
+func (client *Downloader) addHeaders(req *http.Request) {
+	hostHeaders, found := client.hostHeaders[req.URL.Host]
+	if !found {
+		hostHeaders = client.hostHeaders[req.URL.Hostname()]
+	}
+
+	var toolHeaders map[string]string
+	if req.URL.Host == client.toolHeadersHost || req.URL.Hostname() == client.toolHeadersHost {
+		toolHeaders = client.toolHeaders
+	}
+
+	for _, headers := range []map[string]string{hostHeaders, toolHeaders} {
+		for name, value := range headers {
+			req.Header.Set(name, os.ExpandEnv(value))
+		}
+	}
+}
+
+// The same limit as the default of net/http
+const maxRedirects = 10
+
+// Go keeps custom headers when a request is redirected to another host, so
+// the configured headers are removed there and those of the new host are set
+func (client *Downloader) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("Stopped after %d redirects.", maxRedirects)
+	}
+
+	if req.URL.Host == via[len(via)-1].URL.Host {
+		return nil
+	}
+
+	for _, headers := range client.hostHeaders {
+		for name := range headers {
+			req.Header.Del(name)
+		}
+	}
+	for name := range client.toolHeaders {
+		req.Header.Del(name)
+	}
+	client.addHeaders(req)
+
+	return nil
+}
+
+func isValidHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for _, c := range name {
+		if c <= ' ' || c >= 0x7f || strings.ContainsRune("\"(),/:;<=>?@[\\]{}", c) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Returns an error for every header with a name that cannot be sent
+func getHeaderErrors(owner string, headers map[string]string) []error {
+	var result []error
+	for _, name := range slices.Sorted(maps.Keys(headers)) {
+		if !isValidHeaderName(name) {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			result = append(result, fmt.Errorf("Invalid header name '%s' of %s", name, owner))
+		}
+	}
+
+	return result
+}
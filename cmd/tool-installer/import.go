@@ -0,0 +1,192 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Package names of other package managers that differ from the catalog name
+var packageAliases = map[string]string{
+	"btm":            "bottom",
+	"git-delta":      "delta",
+	"github.cli":     "gh",
+	"kubernetes-cli": "kubectl",
+	"rg":             "ripgrep",
+	"tldr":           "tealdeer",
+}
+
+var brewfileRegex = regexp.MustCompile(`^\s*brew\s+["']([^"']+)["']`)
+
+// Returns the formulae of a Brewfile, without their tap
+func parseBrewfile(content []byte) []string {
+	var result []string
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		match := brewfileRegex.FindStringSubmatch(scanner.Text())
+		if match != nil {
+			result = append(result, match[1][strings.LastIndex(match[1], "/")+1:])
+		}
+	}
+
+	return result
+}
+
+// Returns the apps of 'scoop export', which is JSON in newer versions of scoop
+// and one 'name (v:version) [bucket]' line per app in older ones
+func parseScoopExport(content []byte) []string {
+	var result []string
+
+	var export struct {
+		Apps []struct {
+			Name string `json:"Name"`
+		} `json:"apps"`
+	}
+	if json.Unmarshal(content, &export) == nil {
+		for _, app := range export.Apps {
+			result = append(result, app.Name)
+		}
+		return result
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 0 {
+			result = append(result, fields[0])
+		}
+	}
+
+	return result
+}
+
+// Returns the package identifiers of 'winget export', e.g. 'BurntSushi.ripgrep.MSVC'
+func parseWingetExport(content []byte) ([]string, error) {
+	var export struct {
+		Sources []struct {
+			Packages []struct {
+				PackageIdentifier string `json:"PackageIdentifier"`
+			} `json:"Packages"`
+		} `json:"Sources"`
+	}
+
+	err := json.Unmarshal(content, &export)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for _, source := range export.Sources {
+		for _, pkg := range source.Packages {
+			result = append(result, pkg.PackageIdentifier)
+		}
+	}
+
+	return result, nil
+}
+
+// Returns the name of the catalog tool for a package of another package manager
+func findCatalogTool(catalog map[string]Tool, packageName string) (string, bool) {
+	candidates := []string{strings.ToLower(packageName)}
+
+	// winget identifiers are 'Publisher.Name' with optional further parts
+	if parts := strings.Split(candidates[0], "."); len(parts) > 1 {
+		candidates = append(candidates, parts[1])
+	}
+
+	for _, candidate := range candidates {
+		if alias, found := packageAliases[candidate]; found {
+			candidate = alias
+		}
+
+		if _, found := catalog[candidate]; found {
+			return candidate, true
+		}
+	}
+
+	return "", false
+}
+
+// Adds the tools of a Brewfile, scoop or winget export that are in the catalog
+// to the configuration
+func importTools(configLocation *string, format string, filePath string) error {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	var packages []string
+	switch format {
+	case "brewfile":
+		packages = parseBrewfile(content)
+	case "scoop":
+		packages = parseScoopExport(content)
+	case "winget":
+		packages, err = parseWingetExport(content)
+		if err != nil {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return fmt.Errorf("The file is not a winget export: %w.", err)
+		}
+	default:
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("Unknown format '%s', expected 'brewfile', 'scoop' or 'winget'.", format)
+	}
+
+	raw, err := readRawConfiguration(*configLocation)
+	if err != nil {
+		return err
+	}
+	existing, _ := raw["tools"].(map[string]any)
+
+	catalog := getCatalog()
+	added := make(map[string]Tool)
+	var skipped []string
+	for _, pkg := range packages {
+		name, found := findCatalogTool(catalog, pkg)
+		if !found {
+			skipped = append(skipped, pkg)
+			continue
+		}
+
+		if _, exists := existing[name]; exists {
+			fmt.Printf("Tool '%s' is already in the configuration.\n", name)
+			continue
+		}
+
+		if _, exists := added[name]; !exists {
+			added[name] = catalog[name]
+			fmt.Printf("Adding tool '%s' for package '%s'.\n", name, pkg)
+		}
+	}
+
+	if len(skipped) > 0 {
+		sort.Strings(skipped)
+		fmt.Printf("Not in the catalog: %s\n", strings.Join(skipped, ", "))
+	}
+
+	if len(added) == 0 {
+		fmt.Println("No tools were added.")
+		return nil
+	}
+
+	err = addRawTools(raw, added)
+	if err != nil {
+		return err
+	}
+
+	err = writeRawConfiguration(*configLocation, raw)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Added %d tools, install them with 'tooli install'.\n", len(added))
+
+	return nil
+}
@@ -0,0 +1,112 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// How many lines of the release notes 'info' prints
+const maxInfoNotesLines = 15
+
+// Returns the tool's entry as written in the configuration, or as parsed if
+// it cannot be found there, e.g. in a remote configuration
+func getToolEntry(configLocation string, name string, tool *Tool) string {
+	var entry any = tool
+	if raw, err := readRawConfiguration(configLocation); err == nil {
+		if rawTool, err := getRawTool(raw, name); err == nil {
+			entry = rawTool
+		}
+	}
+
+	content, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return ""
+	}
+
+	return string(content)
+}
+
+// Returns the first lines of the release notes, with a note if there are more
+func getNotesExcerpt(body string) string {
+	body = strings.TrimSpace(strings.ReplaceAll(body, "\r\n", "\n"))
+	if body == "" {
+		return "No release notes available."
+	}
+
+	lines := strings.Split(body, "\n")
+	if len(lines) <= maxInfoNotesLines {
+		return body
+	}
+
+	return strings.Join(lines[:maxInfoNotesLines], "\n") + fmt.Sprintf("\n... (%d more lines, see 'tooli changelog')", len(lines)-maxInfoNotesLines)
+}
+
+// Prints the configuration entry and installed version of a tool, and the
+// date, selected asset and release notes of its latest release
+func showToolInfo(configLocation *string, name string, downloadTimeout int) {
+	config, err := getConfig(*configLocation)
+	if err != nil {
+		printConfigError(err)
+		os.Exit(1)
+	}
+
+	tool, found := config.Tools[name]
+	if !found {
+		logError("Tool '%s' not found in configuration.", name)
+		os.Exit(1)
+	}
+
+	cache, err := getCache()
+	if err != nil {
+		logError("Failed to obtain cache. Message: %v", err)
+		os.Exit(1)
+	}
+
+	installed, found := cache.Tools[name]
+	if !found {
+		installed = "not installed"
+	} else if cache.Held[name] {
+		installed += " (held)"
+	}
+
+	fmt.Printf("Tool:        %s\n", name)
+	fmt.Printf("Repository:  %s\n", getToolLink(&tool))
+	fmt.Printf("Description: %s\n", tool.Description)
+	fmt.Printf("Installed:   %s\n", installed)
+
+	downloader, err := newDownloader(downloadTimeout, &config)
+	if err != nil {
+		logError("%v", err)
+		os.Exit(1)
+	}
+
+	source, err := downloader.getSource(&tool)
+	if err != nil {
+		logError("%v", err)
+		os.Exit(1)
+	}
+
+	release, err := getToolRelease(source, &tool)
+	if err != nil {
+		logError("Could not obtain the latest release of tool '%v': %v", name, err)
+		os.Exit(1)
+	}
+
+	asset := "none matches"
+	if selected, err := selectAsset(&release, &tool, &config); err == nil {
+		asset = selected.Name
+	}
+
+	fmt.Printf("Latest:      %s\n", release.TagName)
+	if release.PublishedAt != "" {
+		fmt.Printf("Released:    %s\n", formatDate(release.PublishedAt))
+	}
+	fmt.Printf("Asset:       %s\n", asset)
+
+	fmt.Printf("\nConfiguration:\n%s\n", getToolEntry(*configLocation, name, &tool))
+	fmt.Printf("\nRelease notes of %s:\n%s\n", release.TagName, getNotesExcerpt(release.Body))
+}
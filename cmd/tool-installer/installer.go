@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Returns the directory installers are downloaded to, which defaults to a
+// directory below the data directory
+func (config *Configuration) getDownloadDirectory() (string, error) {
+	if config.DownloadDirectory != "" {
+		return expandPath(config.DownloadDirectory), nil
+	}
+
+	dataDirectory, err := getXdgDirectory("XDG_DATA_HOME", filepath.Join(".local", "share"))
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dataDirectory, "tool-installer", "downloads"), nil
+}
+
+func newInstallerCommand(filePath string, arguments []string) *exec.Cmd {
+	// MSI packages are not executable themselves
+	if strings.EqualFold(filepath.Ext(filePath), ".msi") {
+		return exec.Command("msiexec", append([]string{"/i", filePath}, arguments...)...)
+	}
+
+	return exec.Command(filePath, arguments...)
+}
+
+// Stores the asset of a tool that only ships an installer, e.g. an .msi or
+// .exe setup, in the download directory and runs it with the configured
+// arguments if requested. Returns the path of the stored installer.
+func storeInstaller(data *io.SectionReader, asset *Asset, name string, tool *Tool, config *Configuration) ([]string, error) {
+	err := validateAsset(data, asset, atRaw)
+	if err != nil {
+		return nil, err
+	}
+
+	downloadDirectory, err := config.getDownloadDirectory()
+	if err != nil {
+		return nil, err
+	}
+
+	filePath, err := prepareOutputFile(filepath.Join(downloadDirectory, name), path.Base(asset.Name))
+	if err != nil {
+		return nil, err
+	}
+
+	err = writeFile(filePath, readFromStart(data), 0755)
+	if err != nil {
+		return nil, err
+	}
+	logInfo("Downloaded the installer of '%s' to '%s'.", name, filePath)
+
+	if !tool.RunInstaller {
+		return []string{filePath}, nil
+	}
+
+	logInfo("Running the installer of '%s'.", name)
+	command := newInstallerCommand(filePath, tool.InstallerArgs)
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+
+	err = command.Run()
+	if err != nil {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return nil, fmt.Errorf("The installer of '%s' failed: %v", name, err)
+	}
+
+	return []string{filePath}, nil
+}
@@ -0,0 +1,31 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// Exit code after an interruption, as used by shells for SIGINT
+const interruptedExitCode = 130
+
+// Cancels the downloader's requests on the first SIGINT or SIGTERM, so that
+// the running installations stop and the cache can still be written. A second
+// signal terminates tooli immediately.
+func (client *Downloader) cancelOnInterrupt() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	client.context = ctx
+
+	go func() {
+		<-ctx.Done()
+		stop()
+		logInfo("Interrupted, finishing up. Press Ctrl-C again to quit immediately.")
+	}()
+}
+
+func (client *Downloader) isInterrupted() bool {
+	return client.context.Err() != nil
+}
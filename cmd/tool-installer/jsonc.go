@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+// Removes '//' and '/* */' comments as well as trailing commas from JSON, so
+// that configurations can be annotated. Everything removed is replaced by
+// spaces, keeping the offsets in error messages of the JSON decoder intact.
+func stripJsonComments(content []byte) []byte {
+	result := make([]byte, len(content))
+	copy(result, content)
+
+	inString := false
+	for i := 0; i < len(result); i++ {
+		switch {
+		case inString:
+			if result[i] == '\\' {
+				i++
+			} else if result[i] == '"' {
+				inString = false
+			}
+		case result[i] == '"':
+			inString = true
+		case result[i] == '/' && i+1 < len(result) && result[i+1] == '/':
+			for ; i < len(result) && result[i] != '\n'; i++ {
+				result[i] = ' '
+			}
+		case result[i] == '/' && i+1 < len(result) && result[i+1] == '*':
+			result[i], result[i+1] = ' ', ' '
+			for i += 2; i < len(result) && !(result[i] == '*' && i+1 < len(result) && result[i+1] == '/'); i++ {
+				// Newlines are kept for the line numbers
+				if result[i] != '\n' {
+					result[i] = ' '
+				}
+			}
+			if i < len(result) {
+				result[i], result[i+1] = ' ', ' '
+				i++
+			}
+		}
+	}
+
+	// Comments are gone, so only whitespace can be between a trailing comma
+	// and the closing bracket
+	inString = false
+	for i := 0; i < len(result); i++ {
+		switch {
+		case inString:
+			if result[i] == '\\' {
+				i++
+			} else if result[i] == '"' {
+				inString = false
+			}
+		case result[i] == '"':
+			inString = true
+		case result[i] == ',':
+			j := i + 1
+			for j < len(result) && isJsonWhitespace(result[j]) {
+				j++
+			}
+			if j < len(result) && (result[j] == '}' || result[j] == ']') {
+				result[i] = ' '
+			}
+		}
+	}
+
+	return result
+}
+
+func isJsonWhitespace(character byte) bool {
+	return character == ' ' || character == '\t' || character == '\n' || character == '\r'
+}
@@ -0,0 +1,151 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+type LockedTool struct {
+	Version string `json:"version"`
+	Asset   string `json:"asset,omitempty"`
+	Sha256  string `json:"sha256,omitempty"`
+}
+
+type Lockfile struct {
+	Tools map[string]LockedTool `json:"tools"`
+}
+
+func getLockfilePath(configPath string) string {
+	return filepath.Join(filepath.Dir(configPath), "tooli.lock")
+}
+
+func readLockfile(path string) (Lockfile, error) {
+	var result Lockfile
+
+	bytes, err := os.ReadFile(replaceTildePath(path))
+	if err != nil {
+		return result, err
+	}
+
+	err = json.Unmarshal(bytes, &result)
+
+	return result, err
+}
+
+// Writes the exact versions, assets and digests of all installed tools
+func writeLockfile(configLocation *string, path *string) error {
+	config, err := getConfig(*configLocation)
+	if err != nil {
+		return err
+	}
+
+	cache, err := getCache()
+	if err != nil {
+		return err
+	}
+
+	lockfile := Lockfile{Tools: make(map[string]LockedTool)}
+
+	for name, version := range cache.Tools {
+		if _, found := config.Tools[name]; !found {
+			continue
+		}
+
+		installed := cache.Installed[name]
+		if installed.Sha256 == "" {
+			logWarning("No digest recorded for '%s', reinstall it to lock the exact asset.", name)
+		}
+
+		lockfile.Tools[name] = LockedTool{Version: version, Asset: installed.Asset, Sha256: installed.Sha256}
+	}
+
+	bytes, err := json.MarshalIndent(lockfile, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	filePath := replaceTildePath(*path)
+	lockDir := filepath.Dir(filePath)
+	err = makeOutputDirectory(&lockDir)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(filePath, bytes, 0644)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Locked %d tools in '%s'.\n", len(lockfile.Tools), filePath)
+
+	return nil
+}
+
+func installLockedTools(downloader *Downloader, lockfilePath *string, allowDowngrade bool, force bool, config *Configuration, cache *Cache, jobs int) {
+	lockfile, err := readLockfile(*lockfilePath)
+	if err != nil {
+		logError("Could not read lockfile: %v", err)
+		os.Exit(1)
+	}
+
+	names := make([]string, 0, len(lockfile.Tools))
+	for name := range lockfile.Tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	restoreOutput := downloader.progress.captureOutput()
+
+	var mutex sync.Mutex
+	failed := false
+	var results []ToolResult
+	runParallel(names, jobs, func(name string) {
+		locked := lockfile.Tools[name]
+		oldVersion, _ := cache.getVersion(name)
+
+		var err error
+		result := ToolResult{Tool: name, Action: actionCancelled, OldVersion: oldVersion}
+		if !downloader.isInterrupted() {
+			logInfo("Installing tool '%s@%s'.", name, locked.Version)
+			err = downloader.downloadTool(name, InstallOptions{Version: locked.Version, Asset: locked.Asset, Sha256: locked.Sha256, AllowDowngrade: allowDowngrade, Force: force}, config, cache)
+			switch {
+			case err != nil && downloader.isInterrupted():
+				logInfo("Cancelled installing tool '%s'.", name)
+			case err != nil:
+				logError("Could not install tool '%s': %v", name, err)
+				fallthrough
+			default:
+				newVersion, _ := cache.getVersion(name)
+				result = getInstallResult(name, oldVersion, newVersion, err)
+			}
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		if result.Action == actionFailed {
+			failed = true
+		}
+		results = append(results, result)
+	})
+
+	restoreOutput()
+
+	cache.writeCache()
+	if jsonOutput != nil {
+		writeToolResults(results)
+	} else {
+		printSummary(results)
+	}
+	if downloader.isInterrupted() {
+		os.Exit(interruptedExitCode)
+	}
+	if failed {
+		os.Exit(failedToolsExitCode)
+	}
+}
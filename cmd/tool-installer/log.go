@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+)
+
+// How much tooli prints while it works, selected with the global options
+// '--quiet', '--verbose' and '--debug'
+type LogLevel int
+
+const (
+	// Only errors
+	levelQuiet LogLevel = iota
+	// Progress, results and warnings
+	levelNormal
+	// Also why assets were selected, which files were extracted and cache use
+	levelVerbose
+	// Also every HTTP request
+	levelDebug
+)
+
+var logLevel = levelNormal
+
+// Applies a verbosity option, returns false if the argument is none
+func setLogLevel(arg string) bool {
+	switch arg {
+	case "-q", "--quiet":
+		logLevel = levelQuiet
+	case "-V", "--verbose":
+		if logLevel < levelVerbose {
+			logLevel = levelVerbose
+		}
+	case "--debug":
+		logLevel = levelDebug
+	default:
+		return false
+	}
+
+	return true
+}
+
+// Removes the verbosity options from the global options before the command
+// and returns the rest. After the command, they are parsed with the options of
+// the command, see addGlobalFlags.
+func parseLogLevel(args []string) []string {
+	result := make([]string, 0, len(args))
+	for _, arg := range args {
+		if !setLogLevel(arg) {
+			result = append(result, arg)
+		}
+	}
+
+	return result
+}
+
+func logAt(level LogLevel, prefix string, format string, args ...any) {
+	if logLevel >= level {
+		fmt.Printf(prefix+format+"\n", args...)
+	}
+}
+
+// Prints an error, also in quiet mode
+func logError(format string, args ...any) {
+	logAt(levelQuiet, "Error: ", format, args...)
+}
+
+func logWarning(format string, args ...any) {
+	logAt(levelNormal, "WARNING: ", format, args...)
+}
+
+func logInfo(format string, args ...any) {
+	logAt(levelNormal, "", format, args...)
+}
+
+func logVerbose(format string, args ...any) {
+	logAt(levelVerbose, "", format, args...)
+}
+
+func logDebug(format string, args ...any) {
+	logAt(levelDebug, "DEBUG: ", format, args...)
+}
+
+// Whether only errors are shown, e.g. no progress bars
+func isQuiet() bool {
+	return logLevel == levelQuiet
+}
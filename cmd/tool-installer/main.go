@@ -6,6 +6,8 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 )
 
 const version = "1.5.0"
@@ -22,14 +24,46 @@ USAGE:
     tooli [OPTIONS] <COMMAND>
 
 COMMANDS:
-    i,  install         Installs the newest version of all tools
+    i,  install         Installs the newest version of all (or the given) tools
+        update          Same as 'install'
+        sync            Installs, updates and removes tools to match the configuration
     c,  check           Checks and displays available updates
     cc, create-config   Creates the default configuration
     l,  list            Lists the tools in the configuration, sorted by name
+        lock            Writes the installed versions to a lockfile
+        rollback        Switches a tool back to the previously installed version
+        versions        Lists the available releases of a tool
+        changelog       Prints the release notes of a tool since the installed version
+        info            Shows the configuration and latest release of a tool
+        verify          Checks that installed files were not modified
+        which           Prints the installed version and binaries of a tool
+        ui              Lists the tools in the terminal to install, update or remove them
+        status          Compares the configuration, the cache and the installed files
+        prune           Lists (and with '--yes' deletes) files of tools that are not configured
+        trust           Allows downloads from a repository or lists untrusted ones
+        hold            Excludes tools from updates
+        unhold          Includes held tools in updates again
+        add             Adds a tool to the configuration
+        search          Searches GitHub for tools and offers to add one
+        edit            Changes a tool in the configuration
+        rename          Renames a tool in the configuration and the cache
+        migrate-config  Upgrades the configuration to the current format
+        import          Adds the tools of a Brewfile, scoop or winget export
+        export          Prints tools as a snippet for another configuration
+        catalog         Lists, searches or updates ('catalog update') the known tools
+        config          Manages the configuration ('config validate|edit|get|set')
+        cache           Removes cached downloads ('cache clean')
+        doctor          Checks the setup for common problems and how to fix them
+        completions     Prints the completion script for bash, zsh, fish or powershell
 
 OPTIONS:
     -h, --help      Print this help information
     -v, --version   Print version information
+    --profile NAME  Use the tools and installation directory of the given profile
+    -q, --quiet     Only print errors
+    -V, --verbose   Also print why assets were selected and which files were written
+    --debug         Also print every HTTP request
+    -y, --yes       Confirm all questions and fail instead of waiting for input
 
 For more information about a specific command, try 'tooli <command> --help'.
 `
@@ -40,7 +74,79 @@ func printHelp() {
 	fmt.Print(helpText)
 }
 
+// Parses the flags of a command that takes a single name, which may come
+// before or after the flags. Returns nothing if there is not exactly one name.
+func parseNamedCommand(flagSet *flag.FlagSet, args []string) string {
+	name := ""
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name = args[0]
+		args = args[1:]
+	}
+
+	flagSet.Parse(args)
+
+	switch {
+	case name == "" && flagSet.NArg() == 1:
+		return flagSet.Arg(0)
+	case name != "" && flagSet.NArg() == 0:
+		return name
+	default:
+		return ""
+	}
+}
+
+// Returns how many arguments precede the command, i.e. the global options and
+// the value of '--profile'
+func countGlobalArguments(args []string) int {
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--profile" {
+			i++
+			continue
+		}
+		if !strings.HasPrefix(args[i], "-") {
+			return i
+		}
+	}
+
+	return len(args)
+}
+
+// Accepts the global options after the command as well. They are parsed like
+// the command's own options, so they are never taken from the value of
+// another option or from the arguments after the options.
+func addGlobalFlags(flagSet *flag.FlagSet) {
+	for _, option := range []string{"-q", "--quiet", "-V", "--verbose", "--debug"} {
+		flagSet.BoolFunc(strings.TrimLeft(option, "-"), "Global option, see 'tooli --help'", func(string) error {
+			setLogLevel(option)
+			return nil
+		})
+	}
+
+	for _, name := range []string{"y", "yes", "non-interactive"} {
+		flagSet.BoolFunc(name, "Global option, see 'tooli --help'", func(string) error {
+			assumeYes = true
+			return nil
+		})
+	}
+}
+
+// The options of all commands, the completion scripts skip the values of those
+// that take one
+var commandFlagSets []*flag.FlagSet
+
+// Creates the options of a command, including the global options
+func newCommandFlagSet(name string) *flag.FlagSet {
+	flagSet := flag.NewFlagSet(name, flag.ExitOnError)
+	addGlobalFlags(flagSet)
+	commandFlagSets = append(commandFlagSets, flagSet)
+
+	return flagSet
+}
+
 func main() {
+	globalCount := countGlobalArguments(os.Args[1:])
+	args := append(parseAssumeYes(parseLogLevel(os.Args[1:1+globalCount])), os.Args[1+globalCount:]...)
+	os.Args = append(os.Args[:1], args...)
 	if len(os.Args) < 2 {
 		printHelp()
 		os.Exit(1)
@@ -48,51 +154,532 @@ func main() {
 
 	defaultConfigLocation, err := getConfigFilePath()
 	if err != nil {
-		fmt.Printf("Error obtaining default config file path: %v\n", err)
+		logError("Could not obtain the default configuration path: %v", err)
 		os.Exit(1)
 	}
 
+	defaultLockfileLocation := getLockfilePath(defaultConfigLocation)
+
+	// The only global option that takes a value, so it is removed before the
+	// arguments are passed to the commands
+	if os.Args[1] == "--profile" || strings.HasPrefix(os.Args[1], "--profile=") {
+		name, found := strings.CutPrefix(os.Args[1], "--profile=")
+		consumed := 1
+		if !found {
+			if len(os.Args) < 3 {
+				logError("Expected a profile name after '--profile'.")
+				os.Exit(1)
+			}
+			name = os.Args[2]
+			consumed = 2
+		}
+		profileOverride = name
+		os.Args = append(os.Args[:1], os.Args[1+consumed:]...)
+
+		if len(os.Args) < 2 {
+			printHelp()
+			os.Exit(1)
+		}
+	}
+
 	command := os.Args[1]
 
-	installCommand := flag.NewFlagSet("install", flag.ExitOnError)
+	installCommand := newCommandFlagSet("install")
 	configLocation := installCommand.String("config", defaultConfigLocation, "Location of the configuration file")
 	installOnly := installCommand.String("only", "", "Install only the specified tool instead of all")
 	downloadTimeout := installCommand.Int("timeout", 10, "Timeout limit for requests in seconds")
+	installLocked := installCommand.Bool("locked", false, "Install exactly the versions from the lockfile")
+	installLockfile := installCommand.String("lockfile", defaultLockfileLocation, "Location of the lockfile")
+	allowDowngrade := installCommand.Bool("allow-downgrade", false, "Allow installing versions older than the installed ones")
+	installShowChangelog := installCommand.Bool("show-changelog", false, "Print the release notes since the installed version")
+	installRequireSigned := installCommand.Bool("require-signed", false, "Refuse to install assets without a verified signature")
+	installMinAge := installCommand.String("min-age", "", "Only install releases at least this old, e.g. '7d'")
+	installOutput := installCommand.String("output", "text", "Output format: 'text' or 'json'")
+	installJobs := installCommand.Int("jobs", 0, "Number of tools to install at the same time (default 4)")
+	installLimitRate := installCommand.String("limit-rate", "", "Limit the download speed of assets, e.g. '2M' for 2 MiB/s")
+	installOffline := installCommand.Bool("offline", false, "Install from cached releases and assets without network access")
+	installForce := installCommand.Bool("force", false, "Reinstall tools even if they are up to date")
 
-	checkCommand := flag.NewFlagSet("check", flag.ExitOnError)
+	lockCommand := newCommandFlagSet("lock")
+	lockConfigLocation := lockCommand.String("config", defaultConfigLocation, "Location of the configuration file")
+	lockPath := lockCommand.String("path", defaultLockfileLocation, "Path of the created lockfile")
+
+	checkCommand := newCommandFlagSet("check")
 	checkConfigPath := checkCommand.String("config", defaultConfigLocation, "Location of the configuration file")
 	checkAll := checkCommand.Bool("all", false, "Check all tools, not just installed ones")
 	checkTimeout := checkCommand.Int("timeout", 10, "Timeout limit for requests in seconds")
+	checkOutput := checkCommand.String("output", "text", "Output format: 'text' or 'json'")
+	checkJobs := checkCommand.Int("jobs", 0, "Number of tools to check at the same time (default 4)")
+	checkOffline := checkCommand.Bool("offline", false, "Compare against the cached releases without network access")
 
-	configCommand := flag.NewFlagSet("create-config", flag.ExitOnError)
+	configCommand := newCommandFlagSet("create-config")
 	writeConfigPath := configCommand.String("path", defaultConfigLocation, "Path of the created file")
 
-	listCommand := flag.NewFlagSet("list", flag.ExitOnError)
+	rollbackCommand := newCommandFlagSet("rollback")
+	rollbackConfigLocation := rollbackCommand.String("config", defaultConfigLocation, "Location of the configuration file")
+
+	versionsCommand := newCommandFlagSet("versions")
+	versionsConfigLocation := versionsCommand.String("config", defaultConfigLocation, "Location of the configuration file")
+	versionsLimit := versionsCommand.Int("limit", 20, "Maximum number of releases to list")
+	versionsTimeout := versionsCommand.Int("timeout", 10, "Timeout limit for requests in seconds")
+
+	changelogCommand := newCommandFlagSet("changelog")
+	changelogConfigLocation := changelogCommand.String("config", defaultConfigLocation, "Location of the configuration file")
+	changelogTimeout := changelogCommand.Int("timeout", 10, "Timeout limit for requests in seconds")
+
+	searchCommand := newCommandFlagSet("search")
+	searchConfigLocation := searchCommand.String("config", defaultConfigLocation, "Location of the configuration file")
+	searchLimit := searchCommand.Int("limit", 10, "Maximum number of repositories to list")
+	searchTimeout := searchCommand.Int("timeout", 10, "Timeout limit for requests in seconds")
+
+	infoCommand := newCommandFlagSet("info")
+	infoConfigLocation := infoCommand.String("config", defaultConfigLocation, "Location of the configuration file")
+	infoTimeout := infoCommand.Int("timeout", 10, "Timeout limit for requests in seconds")
+
+	verifyCommand := newCommandFlagSet("verify")
+	verifyConfigLocation := verifyCommand.String("config", defaultConfigLocation, "Location of the configuration file")
+
+	whichCommand := newCommandFlagSet("which")
+	whichConfigLocation := whichCommand.String("config", defaultConfigLocation, "Location of the configuration file")
+
+	syncCommand := newCommandFlagSet("sync")
+	syncConfigLocation := syncCommand.String("config", defaultConfigLocation, "Location of the configuration file")
+	syncTimeout := syncCommand.Int("timeout", 10, "Timeout limit for requests in seconds")
+	syncJobs := syncCommand.Int("jobs", 0, "Number of tools to install at the same time (default 4)")
+	syncDryRun := syncCommand.Bool("dry-run", false, "Only print what would be installed, updated and removed")
+	syncOutput := syncCommand.String("output", "text", "Output format: 'text' or 'json'")
+
+	uiCommand := newCommandFlagSet("ui")
+	uiConfigLocation := uiCommand.String("config", defaultConfigLocation, "Location of the configuration file")
+	uiTimeout := uiCommand.Int("timeout", 10, "Timeout limit for requests in seconds")
+	uiOutput := uiCommand.String("output", "text", "Output format: 'text' or 'json'")
+
+	statusCommand := newCommandFlagSet("status")
+	statusConfigLocation := statusCommand.String("config", defaultConfigLocation, "Location of the configuration file")
+
+	pruneCommand := newCommandFlagSet("prune")
+	pruneConfigLocation := pruneCommand.String("config", defaultConfigLocation, "Location of the configuration file")
+
+	completionsCommand := newCommandFlagSet("completions")
+	completionsConfigLocation := completionsCommand.String("config", defaultConfigLocation, "Location of the configuration file")
+	completionsToolNames := completionsCommand.Bool("tool-names", false, "Print the names of the configured tools, used by the scripts")
+
+	doctorCommand := newCommandFlagSet("doctor")
+	doctorConfigLocation := doctorCommand.String("config", defaultConfigLocation, "Location of the configuration file")
+	doctorTimeout := doctorCommand.Int("timeout", 10, "Timeout limit for requests in seconds")
+
+	trustCommand := newCommandFlagSet("trust")
+	trustConfigLocation := trustCommand.String("config", defaultConfigLocation, "Location of the configuration file")
+	trustRequire := trustCommand.Bool("require", false, "Only install tools from trusted origins from now on")
+
+	holdCommand := newCommandFlagSet("hold")
+	holdConfigLocation := holdCommand.String("config", defaultConfigLocation, "Location of the configuration file")
+
+	listCommand := newCommandFlagSet("list")
 	listConfigLocation := listCommand.String("config", defaultConfigLocation, "Location of the configuration file")
 	listLong := listCommand.Bool("long", false, "List long form")
+	listTag := listCommand.String("tag", "", "List only the tools with the given tag")
+	listOutput := listCommand.String("output", "text", "Output format: 'text' or 'json'")
+
+	validateCommand := newCommandFlagSet("config validate")
+	validateConfigLocation := validateCommand.String("config", defaultConfigLocation, "Location of the configuration file")
+	validateOnline := validateCommand.Bool("online", false, "Also check that the repository of every tool can be reached")
+	validateTimeout := validateCommand.Int("timeout", 10, "Timeout limit for requests in seconds")
+
+	editCommand := newCommandFlagSet("config edit")
+	editConfigLocation := editCommand.String("config", defaultConfigLocation, "Location of the configuration file")
+
+	getCommand := newCommandFlagSet("config get")
+	getConfigLocation := getCommand.String("config", defaultConfigLocation, "Location of the configuration file")
+
+	setCommand := newCommandFlagSet("config set")
+	setConfigLocation := setCommand.String("config", defaultConfigLocation, "Location of the configuration file")
+
+	importCommand := newCommandFlagSet("import")
+	importConfigLocation := importCommand.String("config", defaultConfigLocation, "Location of the configuration file")
+	importFrom := importCommand.String("from", "", "Format of the file: 'brewfile', 'scoop' or 'winget'")
+
+	addCommand := newCommandFlagSet("add")
+	addConfigLocation := addCommand.String("config", defaultConfigLocation, "Location of the configuration file")
+	addFlags := addToolFlags(addCommand)
+	addTimeout := addCommand.Int("timeout", 10, "Timeout limit for requests in seconds")
+
+	editToolCommand := newCommandFlagSet("edit")
+	editToolConfigLocation := editToolCommand.String("config", defaultConfigLocation, "Location of the configuration file")
+	editToolFlags := addToolFlags(editToolCommand)
+
+	renameCommand := newCommandFlagSet("rename")
+	renameConfigLocation := renameCommand.String("config", defaultConfigLocation, "Location of the configuration file")
+	renameBinaries := renameCommand.Bool("binaries", false, "Also rename binaries that are named after the tool")
+
+	exportCommand := newCommandFlagSet("export")
+	exportConfigLocation := exportCommand.String("config", defaultConfigLocation, "Location of the configuration file")
+	exportFormat := exportCommand.String("format", "json", "Format of the snippet: 'json' or 'toml'")
+
+	catalogCommand := newCommandFlagSet("catalog")
+
+	catalogUpdateCommand := newCommandFlagSet("catalog update")
+	catalogUpdateConfigLocation := catalogUpdateCommand.String("config", defaultConfigLocation, "Location of the configuration file")
+	catalogUpdateTimeout := catalogUpdateCommand.Int("timeout", 10, "Timeout limit for requests in seconds")
+
+	cacheCleanCommand := newCommandFlagSet("cache clean")
+	cacheCleanConfigLocation := cacheCleanCommand.String("config", defaultConfigLocation, "Location of the configuration file")
+	cacheCleanMaxSize := cacheCleanCommand.String("max-size", "", "Remove the least recently used downloads above this size, e.g. '2G'")
+	cacheCleanMaxAge := cacheCleanCommand.String("max-age", "", "Remove downloads not used for this long, e.g. '30d'")
+	cacheCleanAll := cacheCleanCommand.Bool("all", false, "Remove all cached and partial downloads")
+
+	migrateCommand := newCommandFlagSet("migrate-config")
+	migrateConfigLocation := migrateCommand.String("config", defaultConfigLocation, "Location of the configuration file")
 
 	switch command {
 	case "-v", "--version":
 		fmt.Println(fullVersion)
 	case "-h", "--help":
 		printHelp()
-	case "i", "install":
+	case "i", "install", "update":
 		installCommand.Parse(os.Args[2:])
-		installTools(configLocation, installOnly, *downloadTimeout)
+		err = setOutputFormat(*installOutput)
+		if err != nil {
+			logError("%v", err)
+			os.Exit(1)
+		}
+		offlineMode = *installOffline
+		var minAge time.Duration
+		if *installMinAge != "" {
+			minAge, err = parseAge(*installMinAge)
+			if err != nil {
+				logError("%v", err)
+				os.Exit(1)
+			}
+		}
+		var limitRate int64
+		if *installLimitRate != "" {
+			limitRate, err = parseSize(*installLimitRate)
+			if err != nil {
+				logError("%v", err)
+				os.Exit(1)
+			}
+		}
+		toolSpecs := installCommand.Args()
+		if *installOnly != "" {
+			toolSpecs = append([]string{*installOnly}, toolSpecs...)
+		}
+		installTools(configLocation, InstallToolsOptions{
+			ToolSpecs:       toolSpecs,
+			DownloadTimeout: *downloadTimeout,
+			Locked:          *installLocked,
+			LockfilePath:    *installLockfile,
+			Jobs:            *installJobs,
+			LimitRate:       limitRate,
+			InstallOptions: InstallOptions{
+				AllowDowngrade: *allowDowngrade,
+				MinAge:         minAge,
+				ShowChangelog:  *installShowChangelog,
+				RequireSigned:  *installRequireSigned,
+				Force:          *installForce,
+			},
+		})
 	case "l", "list":
 		listCommand.Parse(os.Args[2:])
-		listTools(listConfigLocation, *listLong)
+		err = setOutputFormat(*listOutput)
+		if err != nil {
+			logError("%v", err)
+			os.Exit(1)
+		}
+		listTools(listConfigLocation, *listLong, *listTag)
 	case "cc", "create-config":
 		configCommand.Parse(os.Args[2:])
 		err := writeDefaultConfiguration(writeConfigPath)
 		if err != nil {
-			fmt.Println("Error:", err)
+			logError("%v", err)
+		}
+	case "lock":
+		lockCommand.Parse(os.Args[2:])
+		err := writeLockfile(lockConfigLocation, lockPath)
+		if err != nil {
+			logError("%v", err)
+			os.Exit(1)
+		}
+	case "rollback":
+		rollbackCommand.Parse(os.Args[2:])
+		if rollbackCommand.NArg() != 1 {
+			logError("Expected exactly one tool name.")
+			os.Exit(1)
+		}
+		err := rollbackTool(rollbackConfigLocation, rollbackCommand.Arg(0))
+		if err != nil {
+			logError("%v", err)
+			os.Exit(1)
+		}
+	case "versions":
+		versionsCommand.Parse(os.Args[2:])
+		if versionsCommand.NArg() != 1 {
+			logError("Expected exactly one tool name.")
+			os.Exit(1)
+		}
+		listVersions(versionsConfigLocation, versionsCommand.Arg(0), *versionsLimit, *versionsTimeout)
+	case "changelog":
+		changelogCommand.Parse(os.Args[2:])
+		if changelogCommand.NArg() != 1 {
+			logError("Expected exactly one tool name.")
+			os.Exit(1)
+		}
+		showChangelog(changelogConfigLocation, changelogCommand.Arg(0), *changelogTimeout)
+	case "search":
+		searchCommand.Parse(os.Args[2:])
+		if searchCommand.NArg() == 0 {
+			logError("Expected a search query.")
+			os.Exit(1)
+		}
+		err := searchTools(searchConfigLocation, strings.Join(searchCommand.Args(), " "), *searchLimit, *searchTimeout)
+		if err != nil {
+			logError("%v", err)
+			os.Exit(1)
+		}
+	case "info":
+		name := parseNamedCommand(infoCommand, os.Args[2:])
+		if name == "" {
+			logError("Expected exactly one tool name.")
+			os.Exit(1)
+		}
+		showToolInfo(infoConfigLocation, name, *infoTimeout)
+	case "verify":
+		verifyCommand.Parse(os.Args[2:])
+		if !verifyInstalledTools(verifyConfigLocation, verifyCommand.Args()) {
+			os.Exit(1)
+		}
+	case "which":
+		name := parseNamedCommand(whichCommand, os.Args[2:])
+		if name == "" {
+			logError("Expected exactly one tool name.")
+			os.Exit(1)
+		}
+		err := printToolLocation(whichConfigLocation, name)
+		if err != nil {
+			logError("%v", err)
+			os.Exit(1)
+		}
+	case "sync":
+		syncCommand.Parse(os.Args[2:])
+		if *syncDryRun && *syncOutput != "text" {
+			logError("'--dry-run' cannot be combined with '--output'.")
+			os.Exit(1)
+		}
+		err = setOutputFormat(*syncOutput)
+		if err != nil {
+			logError("%v", err)
+			os.Exit(1)
+		}
+		syncTools(syncConfigLocation, *syncTimeout, *syncJobs, *syncDryRun)
+	case "ui":
+		uiCommand.Parse(os.Args[2:])
+		err = setOutputFormat(*uiOutput)
+		if err != nil {
+			logError("%v", err)
+			os.Exit(1)
+		}
+		runToolUi(uiConfigLocation, *uiTimeout)
+	case "status":
+		statusCommand.Parse(os.Args[2:])
+		if !printStatus(statusConfigLocation) {
+			os.Exit(1)
+		}
+	case "prune":
+		pruneCommand.Parse(os.Args[2:])
+		pruneFiles(pruneConfigLocation)
+	case "completions":
+		shell := parseNamedCommand(completionsCommand, os.Args[2:])
+		if *completionsToolNames {
+			printToolNames(completionsConfigLocation)
+			return
+		}
+		if shell == "" {
+			logError("Expected one of bash, zsh, fish or powershell.")
+			os.Exit(1)
+		}
+		script, err := getCompletionScript(shell)
+		if err != nil {
+			logError("%v", err)
+			os.Exit(1)
+		}
+		fmt.Print(script)
+	case "doctor":
+		doctorCommand.Parse(os.Args[2:])
+		if !runDoctor(doctorConfigLocation, *doctorTimeout) {
+			os.Exit(1)
+		}
+	case "trust":
+		trustCommand.Parse(os.Args[2:])
+		trustOrigins(trustConfigLocation, trustCommand.Args(), *trustRequire)
+	case "hold", "unhold":
+		holdCommand.Parse(os.Args[2:])
+		if holdCommand.NArg() == 0 {
+			logError("Expected at least one tool name.")
+			os.Exit(1)
+		}
+		err := setHold(holdConfigLocation, holdCommand.Args(), command == "hold")
+		if err != nil {
+			logError("%v", err)
+			os.Exit(1)
+		}
+	case "import":
+		importCommand.Parse(os.Args[2:])
+		if importCommand.NArg() != 1 || *importFrom == "" {
+			logError("Expected '--from FORMAT' and exactly one file.")
+			os.Exit(1)
+		}
+		err := importTools(importConfigLocation, *importFrom, importCommand.Arg(0))
+		if err != nil {
+			logError("%v", err)
+			os.Exit(1)
+		}
+	case "add":
+		name := parseNamedCommand(addCommand, os.Args[2:])
+		if name == "" {
+			logError("Expected exactly one tool name.")
+			os.Exit(1)
+		}
+		err := addTool(addConfigLocation, name, addCommand, addFlags, *addTimeout)
+		if err != nil {
+			logError("%v", err)
+			os.Exit(1)
+		}
+	case "edit":
+		name := parseNamedCommand(editToolCommand, os.Args[2:])
+		if name == "" {
+			logError("Expected exactly one tool name.")
+			os.Exit(1)
+		}
+		err := editTool(editToolConfigLocation, name, editToolCommand, editToolFlags)
+		if err != nil {
+			logError("%v", err)
+			os.Exit(1)
+		}
+	case "rename":
+		renameCommand.Parse(os.Args[2:])
+		if renameCommand.NArg() != 2 {
+			logError("Expected the old and the new name of the tool.")
+			os.Exit(1)
+		}
+		err := renameTool(renameConfigLocation, renameCommand.Arg(0), renameCommand.Arg(1), *renameBinaries)
+		if err != nil {
+			logError("%v", err)
+			os.Exit(1)
+		}
+	case "export":
+		exportCommand.Parse(os.Args[2:])
+		err := exportTools(exportConfigLocation, exportCommand.Args(), *exportFormat)
+		if err != nil {
+			logError("%v", err)
+			os.Exit(1)
+		}
+	case "catalog":
+		if len(os.Args) > 2 && os.Args[2] == "update" {
+			catalogUpdateCommand.Parse(os.Args[3:])
+			err := updateCatalog(catalogUpdateConfigLocation, *catalogUpdateTimeout)
+			if err != nil {
+				logError("%v", err)
+				os.Exit(1)
+			}
+			break
+		}
+
+		catalogCommand.Parse(os.Args[2:])
+		if catalogCommand.NArg() > 1 {
+			logError("Expected at most one search term.")
+			os.Exit(1)
+		}
+		listCatalog(catalogCommand.Arg(0))
+	case "cache":
+		if len(os.Args) < 3 || os.Args[2] != "clean" {
+			logError("Expected the subcommand 'clean'.")
+			os.Exit(1)
+		}
+		cacheCleanCommand.Parse(os.Args[3:])
+		maxSize := int64(-1)
+		if *cacheCleanMaxSize != "" {
+			maxSize, err = parseSize(*cacheCleanMaxSize)
+			if err != nil {
+				logError("%v", err)
+				os.Exit(1)
+			}
+		}
+		var maxAge time.Duration
+		if *cacheCleanMaxAge != "" {
+			maxAge, err = parseAge(*cacheCleanMaxAge)
+			if err != nil {
+				logError("%v", err)
+				os.Exit(1)
+			}
+		}
+		err := cleanCache(cacheCleanConfigLocation, maxSize, maxAge, *cacheCleanAll)
+		if err != nil {
+			logError("%v", err)
+			os.Exit(1)
+		}
+	case "migrate-config":
+		migrateCommand.Parse(os.Args[2:])
+		err := migrateConfiguration(migrateConfigLocation)
+		if err != nil {
+			logError("%v", err)
+			os.Exit(1)
+		}
+	case "config":
+		if len(os.Args) < 3 {
+			logError("Expected a subcommand, 'validate', 'edit', 'get' or 'set'.")
+			os.Exit(1)
+		}
+		switch subcommand := os.Args[2]; subcommand {
+		case "validate":
+			validateCommand.Parse(os.Args[3:])
+			if !validateConfiguration(validateConfigLocation, *validateOnline, *validateTimeout) {
+				os.Exit(1)
+			}
+		case "edit":
+			editCommand.Parse(os.Args[3:])
+			err := editConfiguration(editConfigLocation)
+			if err != nil {
+				logError("%v", err)
+				os.Exit(1)
+			}
+		case "get":
+			getCommand.Parse(os.Args[3:])
+			if getCommand.NArg() != 1 {
+				logError("Expected exactly one key, e.g. 'tools.ripgrep.linux_asset'.")
+				os.Exit(1)
+			}
+			err := printConfigValue(getConfigLocation, getCommand.Arg(0))
+			if err != nil {
+				logError("%v", err)
+				os.Exit(1)
+			}
+		case "set":
+			setCommand.Parse(os.Args[3:])
+			if setCommand.NArg() != 2 {
+				logError("Expected a key and a value.")
+				os.Exit(1)
+			}
+			err := setConfigValue(setConfigLocation, setCommand.Arg(0), setCommand.Arg(1))
+			if err != nil {
+				logError("%v", err)
+				os.Exit(1)
+			}
+		default:
+			logError("Invalid subcommand '%s', expected 'validate', 'edit', 'get' or 'set'.", subcommand)
+			os.Exit(1)
 		}
 	case "c", "check":
 		checkCommand.Parse((os.Args[2:]))
-		checkToolVersions(checkConfigPath, *checkAll, *checkTimeout)
+		err = setOutputFormat(*checkOutput)
+		if err != nil {
+			logError("%v", err)
+			os.Exit(1)
+		}
+		offlineMode = *checkOffline
+		checkToolVersions(checkConfigPath, *checkAll, *checkTimeout, *checkJobs)
 	default:
-		fmt.Printf("Error: Invalid command '%s'.\n\n", command)
+		logError("Invalid command '%s'.", command)
+		fmt.Println()
 		printHelp()
 		os.Exit(1)
 	}
@@ -0,0 +1,180 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Version of the configuration format written by this version of tooli.
+// Files without a schema_version are version 1.
+const currentSchemaVersion = 2
+
+func getSchemaVersion(raw map[string]any) (int, error) {
+	switch version := raw["schema_version"].(type) {
+	case nil:
+		return 1, nil
+	case float64:
+		return int(version), nil
+	case int64:
+		return int(version), nil
+	default:
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return 0, fmt.Errorf("Invalid schema_version '%v', expected a number", version)
+	}
+}
+
+// Upgrades a configuration document of an older schema in place and returns
+// whether any of its fields had to be changed. Version 1 covers the formats
+// used before schema_version existed:
+//   - 'tools' as a list of objects with a 'name' instead of an object
+//   - a single 'binary' (with an optional 'rename_to') instead of 'binaries'
+//   - 'binaries' as a list of plain names
+//   - 'repo' as 'owner/repository' instead of separate fields
+//   - tools without a 'description'
+func migrateRawConfiguration(raw map[string]any) (bool, error) {
+	version, err := getSchemaVersion(raw)
+	if err != nil {
+		return false, err
+	}
+
+	if version > currentSchemaVersion {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return false, fmt.Errorf("The configuration has schema_version %d, but this version of tooli only supports up to %d, please update tooli", version, currentSchemaVersion)
+	}
+
+	if version == currentSchemaVersion {
+		return false, nil
+	}
+
+	changed := false
+
+	if list, ok := raw["tools"].([]any); ok {
+		tools := make(map[string]any, len(list))
+		for _, entry := range list {
+			tool, ok := entry.(map[string]any)
+			if !ok {
+				//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+				return false, errors.New("Every entry of the 'tools' list needs to be an object")
+			}
+
+			name, _ := tool["name"].(string)
+			if name == "" {
+				//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+				return false, errors.New("Every entry of the 'tools' list needs a 'name'")
+			}
+			delete(tool, "name")
+			tools[name] = tool
+		}
+
+		raw["tools"] = tools
+		changed = true
+	}
+
+	tools, _ := raw["tools"].(map[string]any)
+	for _, entry := range tools {
+		tool, ok := entry.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		if migrateRawTool(tool) {
+			changed = true
+		}
+	}
+
+	return changed, nil
+}
+
+func migrateRawTool(tool map[string]any) bool {
+	changed := false
+
+	if binary, ok := tool["binary"].(string); ok {
+		renameTo, _ := tool["rename_to"].(string)
+		if _, found := tool["binaries"]; !found {
+			tool["binaries"] = []any{map[string]any{"name": binary, "rename_to": renameTo}}
+		}
+		delete(tool, "binary")
+		delete(tool, "rename_to")
+		changed = true
+	}
+
+	if binaries, ok := tool["binaries"].([]any); ok {
+		for i, binary := range binaries {
+			if name, ok := binary.(string); ok {
+				binaries[i] = map[string]any{"name": name, "rename_to": ""}
+				changed = true
+			}
+		}
+	}
+
+	if repo, ok := tool["repo"].(string); ok {
+		owner, repository, found := strings.Cut(repo, "/")
+		if found && tool["owner"] == nil && tool["repository"] == nil {
+			tool["owner"] = owner
+			tool["repository"] = repository
+			delete(tool, "repo")
+			changed = true
+		}
+	}
+
+	if _, found := tool["description"]; !found {
+		tool["description"] = ""
+	}
+
+	return changed
+}
+
+// Upgrades the configuration file to the current schema, the previous
+// content is kept next to it with a '.bak' suffix
+func migrateConfiguration(configLocation *string) error {
+	if isRemoteLocation(*configLocation) {
+		return errRemoteConfiguration(*configLocation)
+	}
+
+	filePath := replaceTildePath(*configLocation)
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	raw, err := decodeRawConfiguration(filePath, content)
+	if err != nil {
+		return err
+	}
+
+	version, err := getSchemaVersion(raw)
+	if err != nil {
+		return err
+	}
+
+	_, err = migrateRawConfiguration(raw)
+	if err != nil {
+		return err
+	}
+
+	if version == currentSchemaVersion {
+		fmt.Println("The configuration is already up to date.")
+		return nil
+	}
+
+	raw["schema_version"] = currentSchemaVersion
+
+	err = os.WriteFile(filePath+".bak", content, 0644)
+	if err != nil {
+		return err
+	}
+
+	err = writeRawConfiguration(filePath, raw)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Upgraded the configuration from schema version %d to %d, the previous file was saved as '%s'.\n", version, currentSchemaVersion, filePath+".bak")
+
+	return nil
+}
@@ -0,0 +1,196 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// Minisign and signify keys and signatures start with the algorithm, 'Ed' for
+// signatures over the data itself and 'ED' for signatures over its BLAKE2b hash
+const (
+	minisignLegacyAlgorithm   = "Ed"
+	minisignPrehashAlgorithm  = "ED"
+	minisignKeyIdLength       = 8
+	minisignTrustedCommentTag = "trusted comment: "
+)
+
+type minisignPublicKey struct {
+	keyId [minisignKeyIdLength]byte
+	key   ed25519.PublicKey
+}
+
+type minisignSignature struct {
+	algorithm       string
+	keyId           [minisignKeyIdLength]byte
+	signature       []byte
+	trustedComment  string
+	globalSignature []byte
+}
+
+// Returns the base64 encoded lines of a key or signature file, skipping comments
+func getMinisignLines(content string) []string {
+	var result []string
+	for _, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "untrusted comment:") {
+			result = append(result, line)
+		}
+	}
+
+	return result
+}
+
+// Parses a public key given either as the base64 line or the whole '.pub' file
+func parseMinisignPublicKey(content string) (minisignPublicKey, error) {
+	var result minisignPublicKey
+
+	lines := getMinisignLines(content)
+	if len(lines) != 1 {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return result, fmt.Errorf("Invalid minisign public key.")
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(lines[0])
+	if err != nil || len(raw) != 2+minisignKeyIdLength+ed25519.PublicKeySize || string(raw[:2]) != minisignLegacyAlgorithm {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return result, fmt.Errorf("Invalid minisign public key.")
+	}
+
+	copy(result.keyId[:], raw[2:2+minisignKeyIdLength])
+	result.key = ed25519.PublicKey(raw[2+minisignKeyIdLength:])
+
+	return result, nil
+}
+
+// Parses '.minisig' files as well as signify '.sig' files, which lack the trusted comment
+func parseMinisignSignature(content string) (minisignSignature, error) {
+	var result minisignSignature
+
+	lines := getMinisignLines(content)
+	if len(lines) != 1 && len(lines) != 3 {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return result, fmt.Errorf("Invalid minisign signature.")
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(lines[0])
+	if err != nil || len(raw) != 2+minisignKeyIdLength+ed25519.SignatureSize {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return result, fmt.Errorf("Invalid minisign signature.")
+	}
+
+	result.algorithm = string(raw[:2])
+	copy(result.keyId[:], raw[2:2+minisignKeyIdLength])
+	result.signature = raw[2+minisignKeyIdLength:]
+
+	if len(lines) == 3 {
+		comment, found := strings.CutPrefix(lines[1], minisignTrustedCommentTag)
+		if !found {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return result, fmt.Errorf("Invalid minisign signature, the trusted comment is missing.")
+		}
+		result.trustedComment = comment
+
+		result.globalSignature, err = base64.StdEncoding.DecodeString(lines[2])
+		if err != nil || len(result.globalSignature) != ed25519.SignatureSize {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return result, fmt.Errorf("Invalid minisign signature.")
+		}
+	}
+
+	return result, nil
+}
+
+func verifyMinisign(data io.Reader, signatureContent string, publicKeyContent string) error {
+	publicKey, err := parseMinisignPublicKey(publicKeyContent)
+	if err != nil {
+		return err
+	}
+
+	signature, err := parseMinisignSignature(signatureContent)
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(signature.keyId[:], publicKey.keyId[:]) {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("The signature was made with a different key.")
+	}
+
+	var message []byte
+	switch signature.algorithm {
+	case minisignLegacyAlgorithm:
+		// Legacy signatures cover the whole data, which has to be read into memory
+		message, err = io.ReadAll(data)
+	case minisignPrehashAlgorithm:
+		hash, _ := blake2b.New512(nil)
+		_, err = io.Copy(hash, data)
+		message = hash.Sum(nil)
+	default:
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("Unsupported signature algorithm '%s'.", signature.algorithm)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !ed25519.Verify(publicKey.key, message, signature.signature) {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("The signature does not match.")
+	}
+
+	// The global signature protects the trusted comment, signify has none
+	if signature.globalSignature != nil {
+		global := append(bytes.Clone(signature.signature), []byte(signature.trustedComment)...)
+		if !ed25519.Verify(publicKey.key, global, signature.globalSignature) {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return fmt.Errorf("The trusted comment of the signature does not match.")
+		}
+	}
+
+	return nil
+}
+
+// Downloads the minisign or signify signature of the asset and verifies it
+// with the tool's public key
+func verifyMinisignAsset(source Source, release *Release, asset *Asset, data *io.SectionReader, publicKey string) error {
+	var signatureAsset *Asset
+	for _, suffix := range []string{".minisig", ".sig"} {
+		for i := range release.Assets {
+			if release.Assets[i].Name == asset.Name+suffix {
+				signatureAsset = &release.Assets[i]
+				break
+			}
+		}
+		if signatureAsset != nil {
+			break
+		}
+	}
+
+	if signatureAsset == nil {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("A minisign public key is configured, but the release contains no signature for '%s'.", asset.Name)
+	}
+
+	signature, err := readAsset(source, signatureAsset)
+	if err != nil {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("Could not download the signature '%s': %v", signatureAsset.Name, err)
+	}
+
+	err = verifyMinisign(readFromStart(data), string(signature), publicKey)
+	if err != nil {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("Could not verify '%s' with '%s': %v", asset.Name, signatureAsset.Name, err)
+	}
+
+	logInfo("Verified the signature of '%s'.", asset.Name)
+	return nil
+}
@@ -0,0 +1,345 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
+)
+
+const ociManifestAccept = "application/vnd.oci.image.manifest.v1+json, application/vnd.oci.image.index.v1+json, application/vnd.docker.distribution.manifest.v2+json, application/vnd.docker.distribution.manifest.list.v2+json"
+
+const ociTitleAnnotation = "org.opencontainers.image.title"
+const ociVersionAnnotation = "org.opencontainers.image.version"
+const ociCreatedAnnotation = "org.opencontainers.image.created"
+
+type OciDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations"`
+	Platform    *struct {
+		Architecture string `json:"architecture"`
+		Os           string `json:"os"`
+	} `json:"platform"`
+}
+
+// Image manifest or image index, depending on which fields are set
+type OciManifest struct {
+	MediaType   string            `json:"mediaType"`
+	Layers      []OciDescriptor   `json:"layers"`
+	Manifests   []OciDescriptor   `json:"manifests"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// Source for binaries published as OCI artifacts, e.g. with ORAS on ghcr.io.
+// Each layer of the artifact is treated as an asset, named by its title.
+type OciSource struct {
+	client      *Downloader
+	registry    string
+	name        string
+	tag         string
+	token       string
+	bearerToken string
+}
+
+func newOciSource(client *Downloader, tool *Tool, token string) (*OciSource, error) {
+	registry, name, found := strings.Cut(tool.OciImage, "/")
+	if !found || name == "" {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return nil, fmt.Errorf("Invalid OCI image '%s', expected 'registry/name'.", tool.OciImage)
+	}
+
+	tag := tool.OciTag
+	if tag == "" {
+		tag = "latest"
+	}
+
+	return &OciSource{client: client, registry: registry, name: name, tag: tag, token: token}, nil
+}
+
+func (source *OciSource) getUrl(kind string, reference string) string {
+	return fmt.Sprintf("https://%s/v2/%s/%s/%s", source.registry, source.name, kind, reference)
+}
+
+// Parses a 'WWW-Authenticate: Bearer realm="...",service="...",scope="..."' header
+func parseBearerChallenge(header string) map[string]string {
+	result := make(map[string]string)
+
+	rest, found := strings.CutPrefix(header, "Bearer ")
+	if !found {
+		return result
+	}
+
+	for _, part := range strings.Split(rest, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(part), "=")
+		if found {
+			result[key] = strings.Trim(value, `"`)
+		}
+	}
+
+	return result
+}
+
+func (source *OciSource) fetchBearerToken(challenge string) error {
+	params := parseBearerChallenge(challenge)
+
+	realm, found := params["realm"]
+	if !found {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return errors.New("The registry requires authentication but did not say how.")
+	}
+
+	query := url.Values{}
+	if service, found := params["service"]; found {
+		query.Set("service", service)
+	}
+	if scope, found := params["scope"]; found {
+		query.Set("scope", scope)
+	} else {
+		query.Set("scope", fmt.Sprintf("repository:%s:pull", source.name))
+	}
+
+	req, err := http.NewRequestWithContext(source.client.context, http.MethodGet, source.client.rewriteUrl(realm+"?"+query.Encode()), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("User-Agent", userAgent)
+	if source.token != "" {
+		req.SetBasicAuth("tooli", source.token)
+	}
+
+	resp, err := source.client.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("Could not obtain a registry token, got status code '%v'.", resp.StatusCode)
+	}
+
+	var tokenResponse struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&tokenResponse)
+	if err != nil {
+		return err
+	}
+
+	source.bearerToken = tokenResponse.Token
+	if source.bearerToken == "" {
+		source.bearerToken = tokenResponse.AccessToken
+	}
+
+	return nil
+}
+
+func (source *OciSource) fetch(rawUrl string, accept string) ([]byte, error) {
+	body, err := source.open(rawUrl, accept)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	return io.ReadAll(body)
+}
+
+// Performs a GET request against the registry, handling the token challenge
+// registries answer anonymous requests with. The caller closes the body.
+func (source *OciSource) open(rawUrl string, accept string) (io.ReadCloser, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		req, err := http.NewRequestWithContext(source.client.context, http.MethodGet, source.client.rewriteUrl(rawUrl), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Add("Accept", accept)
+		req.Header.Add("User-Agent", userAgent)
+		if source.bearerToken != "" {
+			req.Header.Add("Authorization", "Bearer "+source.bearerToken)
+		}
+
+		var resp *http.Response
+		if accept == "application/octet-stream" {
+			resp, err = source.client.doAsset(req)
+		} else {
+			resp, err = source.client.do(req)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			challenge := resp.Header.Get("WWW-Authenticate")
+			discardResponse(resp)
+
+			err = source.fetchBearerToken(challenge)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			discardResponse(resp)
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return nil, fmt.Errorf("Got non-OK status code '%v' from registry '%s'.", resp.StatusCode, source.registry)
+		}
+
+		return resp.Body, nil
+	}
+
+	//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+	return nil, fmt.Errorf("Could not authenticate against registry '%s'.", source.registry)
+}
+
+func (source *OciSource) fetchManifest(reference string) (OciManifest, error) {
+	var result OciManifest
+
+	body, err := source.fetch(source.getUrl("manifests", reference), ociManifestAccept)
+	if err != nil {
+		return result, err
+	}
+
+	err = json.Unmarshal(body, &result)
+
+	return result, err
+}
+
+func (source *OciSource) ListReleases(limit int) ([]Release, error) {
+	body, err := source.fetch(source.getUrl("tags", "list"), "application/json")
+	if err != nil {
+		return nil, err
+	}
+
+	var tags struct {
+		Tags []string `json:"tags"`
+	}
+	err = json.Unmarshal(body, &tags)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Release, 0, len(tags.Tags))
+	for i := len(tags.Tags) - 1; i >= 0; i-- {
+		result = append(result, Release{TagName: tags.Tags[i], Name: tags.Tags[i]})
+	}
+
+	return limitReleases(result, limit), nil
+}
+
+func (source *OciSource) GetLatest() (Release, error) {
+	return source.GetByTag(source.tag)
+}
+
+func (source *OciSource) GetByTag(tag string) (Release, error) {
+	var result Release
+
+	manifest, err := source.fetchManifest(tag)
+	if err != nil {
+		return result, err
+	}
+
+	digest := ""
+
+	// Multi-platform artifacts have an index pointing to one manifest per platform
+	if len(manifest.Manifests) > 0 {
+		for _, m := range manifest.Manifests {
+			if m.Platform == nil || (m.Platform.Os == runtime.GOOS && m.Platform.Architecture == runtime.GOARCH) {
+				digest = m.Digest
+				break
+			}
+		}
+
+		if digest == "" {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return result, fmt.Errorf("The OCI image has no manifest for %s/%s.", runtime.GOOS, runtime.GOARCH)
+		}
+
+		manifest, err = source.fetchManifest(digest)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	if version, found := manifest.Annotations[ociVersionAnnotation]; found {
+		result.TagName = version
+	} else if tag != "latest" || digest == "" {
+		result.TagName = tag
+	} else {
+		result.TagName = digest
+	}
+	result.PublishedAt = manifest.Annotations[ociCreatedAnnotation]
+
+	for _, layer := range manifest.Layers {
+		name, found := layer.Annotations[ociTitleAnnotation]
+		if !found {
+			name = layer.Digest
+		}
+
+		result.Assets = append(result.Assets, Asset{
+			Name:               name,
+			ContentType:        layer.MediaType,
+			Size:               layer.Size,
+			NodeId:             layer.Digest,
+			BrowserDownloadUrl: source.getUrl("blobs", layer.Digest),
+		})
+	}
+
+	return result, nil
+}
+
+// Layers are always downloaded completely, as they are verified as a whole
+func (source *OciSource) OpenAsset(asset *Asset, offset int64) (io.ReadCloser, error) {
+	body, err := source.open(asset.BrowserDownloadUrl, "application/octet-stream")
+	if err != nil {
+		return nil, err
+	}
+
+	// Layers are content-addressed, so the download can always be verified
+	if expected, found := strings.CutPrefix(asset.NodeId, "sha256:"); found {
+		return &digestReader{body: body, hash: sha256.New(), expected: expected, name: asset.Name}, nil
+	}
+
+	return body, nil
+}
+
+// Verifies the SHA-256 digest of a layer once it has been read completely
+type digestReader struct {
+	body     io.ReadCloser
+	hash     hash.Hash
+	expected string
+	name     string
+}
+
+func (reader *digestReader) Read(buffer []byte) (int, error) {
+	count, err := reader.body.Read(buffer)
+	reader.hash.Write(buffer[:count])
+
+	if err == io.EOF && hex.EncodeToString(reader.hash.Sum(nil)) != reader.expected {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return count, fmt.Errorf("The downloaded layer of '%s' does not match its digest.", reader.name)
+	}
+
+	return count, err
+}
+
+func (reader *digestReader) Close() error {
+	return reader.body.Close()
+}
+
+func (source *OciSource) DownloadSourceArchive(release *Release, file *os.File) error {
+	return errNoSourceArchive("OCI artifacts")
+}
@@ -0,0 +1,374 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Set by '--offline', no requests are made and releases and assets are taken
+// from the cache instead
+var offlineMode bool
+
+// Guards the cached release information while tools are installed in parallel
+var releaseCacheMutex sync.Mutex
+
+// The release information of a source that was fetched before
+type CachedReleases struct {
+	Latest   *Release           `json:"latest,omitempty"`
+	Releases []Release          `json:"releases,omitempty"`
+	Tags     map[string]Release `json:"tags,omitempty"`
+}
+
+// Stores the releases and the small assets like checksums and signatures that
+// are read through the source, so that they are available offline later
+type CachingSource struct {
+	Source
+	key string
+	// The download cache, see Downloader.downloadCache
+	downloads string
+}
+
+// Serves the releases and assets that were cached by a CachingSource with the
+// same key, without any requests
+type OfflineSource struct {
+	key       string
+	downloads string
+	// The release of the assets, looked up in the cached releases if empty
+	tag string
+}
+
+func errOffline(location string) error {
+	//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+	return fmt.Errorf("Cannot download '%s' in offline mode", location)
+}
+
+func errNotCached(what string) error {
+	//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+	return fmt.Errorf("%s not available offline, install the tool once without '--offline' first", what)
+}
+
+// Identifies where the releases of a tool come from, independent of its name
+func getSourceKey(tool *Tool) string {
+	fields := []string{tool.Host, tool.Owner, tool.Repository, tool.UrlTemplate, tool.VersionUrl, tool.VersionRegex,
+		tool.OciImage, tool.OciTag, tool.Gist, tool.GistRevision}
+	hash := sha256.Sum256([]byte(strings.Join(fields, "\n")))
+
+	return hex.EncodeToString(hash[:8])
+}
+
+func getOfflineCacheDirectory(kind string, key string) (string, error) {
+	cacheFilePath, err := getCacheFilePath()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(filepath.Dir(cacheFilePath), kind, key), nil
+}
+
+func readCachedReleases(key string) (CachedReleases, error) {
+	var result CachedReleases
+
+	directory, err := getOfflineCacheDirectory("releases", key)
+	if err != nil {
+		return result, err
+	}
+
+	content, err := os.ReadFile(directory + ".json")
+	if err != nil {
+		return result, err
+	}
+
+	err = json.Unmarshal(content, &result)
+	return result, err
+}
+
+// Applies the change to the cached release information of the source. Errors
+// are ignored, the cache only matters for later offline runs.
+func updateCachedReleases(key string, change func(cached *CachedReleases)) {
+	releaseCacheMutex.Lock()
+	defer releaseCacheMutex.Unlock()
+
+	directory, err := getOfflineCacheDirectory("releases", key)
+	if err != nil {
+		return
+	}
+
+	cached, _ := readCachedReleases(key)
+	change(&cached)
+
+	content, err := json.Marshal(cached)
+	if err != nil {
+		return
+	}
+
+	parent := filepath.Dir(directory)
+	if makeOutputDirectory(&parent) == nil {
+		os.WriteFile(directory+".json", content, 0644)
+	}
+}
+
+// Returns the tag of the cached release the asset belongs to
+func (cached *CachedReleases) findTag(asset *Asset) string {
+	releases := cached.Releases
+	if cached.Latest != nil {
+		releases = append([]Release{*cached.Latest}, releases...)
+	}
+	tags := make([]string, 0, len(cached.Tags))
+	for tag := range cached.Tags {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	for _, tag := range tags {
+		releases = append(releases, cached.Tags[tag])
+	}
+
+	for _, release := range releases {
+		for _, other := range release.Assets {
+			if other.Name == asset.Name && other.BrowserDownloadUrl == asset.BrowserDownloadUrl {
+				return release.TagName
+			}
+		}
+	}
+
+	return ""
+}
+
+// Returns the default download cache in the cache directory
+func getDefaultDownloadCache() (string, error) {
+	cacheFilePath, err := getCacheFilePath()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(filepath.Dir(cacheFilePath), "downloads"), nil
+}
+
+// Returns the download cache, which can be shared between machines with the
+// top-level 'download_cache' entry
+func (config *Configuration) getDownloadCache() (string, error) {
+	if config.DownloadCache != "" {
+		return expandPath(config.DownloadCache), nil
+	}
+
+	return getDefaultDownloadCache()
+}
+
+// Returns the directory the assets of a release are kept in, one per source
+// and tag
+func getCachedAssetDirectory(downloads string, key string, tag string) string {
+	name := url.PathEscape(tag)
+	if name == "" || name == "." || name == ".." {
+		name = "_"
+	}
+
+	return filepath.Join(downloads, key, name)
+}
+
+func getCachedAssetPath(downloads string, key string, tag string, asset *Asset) (string, error) {
+	if downloads == "" {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return "", errors.New("No download cache")
+	}
+
+	if tag == "" {
+		cached, _ := readCachedReleases(key)
+		tag = cached.findTag(asset)
+	}
+
+	return filepath.Join(getCachedAssetDirectory(downloads, key, tag), filepath.Base(asset.Name)), nil
+}
+
+func (source *CachingSource) ListReleases(limit int) ([]Release, error) {
+	releases, err := source.Source.ListReleases(limit)
+	if err == nil {
+		updateCachedReleases(source.key, func(cached *CachedReleases) { cached.Releases = releases })
+	}
+
+	return releases, err
+}
+
+func (source *CachingSource) GetLatest() (Release, error) {
+	release, err := source.Source.GetLatest()
+	if err == nil {
+		updateCachedReleases(source.key, func(cached *CachedReleases) { cached.Latest = &release })
+	}
+
+	return release, err
+}
+
+func (source *CachingSource) GetByTag(tag string) (Release, error) {
+	release, err := source.Source.GetByTag(tag)
+	if err == nil {
+		updateCachedReleases(source.key, func(cached *CachedReleases) {
+			if cached.Tags == nil {
+				cached.Tags = make(map[string]Release)
+			}
+			cached.Tags[tag] = release
+		})
+	}
+
+	return release, err
+}
+
+// Keeps a copy of an asset that was read into memory
+func (source *CachingSource) storeAsset(asset *Asset, content []byte) {
+	filePath, err := getCachedAssetPath(source.downloads, source.key, "", asset)
+	if err != nil {
+		return
+	}
+
+	directory := filepath.Dir(filePath)
+	if makeOutputDirectory(&directory) == nil {
+		os.WriteFile(filePath, content, 0644)
+	}
+}
+
+func (source *OfflineSource) ListReleases(limit int) ([]Release, error) {
+	cached, _ := readCachedReleases(source.key)
+	if cached.Releases == nil {
+		return nil, errNotCached("The list of releases is")
+	}
+
+	return limitReleases(cached.Releases, limit), nil
+}
+
+func (source *OfflineSource) GetLatest() (Release, error) {
+	cached, _ := readCachedReleases(source.key)
+	if cached.Latest != nil {
+		return *cached.Latest, nil
+	}
+
+	if cached.Releases != nil {
+		return getNewestRelease(cached.Releases, false)
+	}
+
+	return Release{}, errNotCached("The latest release is")
+}
+
+func (source *OfflineSource) GetByTag(tag string) (Release, error) {
+	cached, _ := readCachedReleases(source.key)
+	if release, found := cached.Tags[tag]; found {
+		return release, nil
+	}
+
+	if cached.Latest != nil && cached.Latest.TagName == tag {
+		return *cached.Latest, nil
+	}
+
+	for _, release := range cached.Releases {
+		if release.TagName == tag {
+			return release, nil
+		}
+	}
+
+	return Release{}, errNotCached(fmt.Sprintf("The release '%s' is", tag))
+}
+
+func (source *OfflineSource) OpenAsset(asset *Asset, offset int64) (io.ReadCloser, error) {
+	filePath, err := getCachedAssetPath(source.downloads, source.key, source.tag, asset)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, errNotCached(fmt.Sprintf("The asset '%s' is", asset.Name))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	if offset > 0 && offset < stat.Size() {
+		_, err = file.Seek(offset, io.SeekStart)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		return &ResponseBody{ReadCloser: file, contentLength: stat.Size() - offset, partial: true}, nil
+	}
+
+	return &ResponseBody{ReadCloser: file, contentLength: stat.Size()}, nil
+}
+
+func (source *OfflineSource) DownloadSourceArchive(release *Release, file *os.File) error {
+	return errNotCached("The source archive is")
+}
+
+// Returns the key and the download cache of a source that uses the cache
+func getSourceCache(source Source) (string, string, bool) {
+	switch source := source.(type) {
+	case *CachingSource:
+		return source.key, source.downloads, true
+	case *OfflineSource:
+		return source.key, source.downloads, true
+	}
+
+	return "", "", false
+}
+
+// Returns a source that reads the asset of the release from the download
+// cache if it was downloaded before, and the given source otherwise
+func getCachedAssetSource(source Source, release *Release, asset *Asset) (Source, bool) {
+	key, downloads, ok := getSourceCache(source)
+	if !ok {
+		return source, false
+	}
+
+	filePath, err := getCachedAssetPath(downloads, key, release.TagName, asset)
+	if err != nil {
+		return source, false
+	}
+
+	if _, err := os.Stat(filePath); err != nil {
+		return source, false
+	}
+
+	return &OfflineSource{key: key, downloads: downloads, tag: release.TagName}, true
+}
+
+// Moves the downloaded asset of an installed tool into the download cache, so
+// that later installs and offline installs can use it. The download cache may
+// be on another file system, in which case the file is copied.
+func keepDownloadedAsset(source Source, release *Release, asset *Asset, file *os.File) {
+	key, downloads, ok := getSourceCache(source)
+	if !ok {
+		return
+	}
+
+	filePath, err := getCachedAssetPath(downloads, key, release.TagName, asset)
+	if err != nil {
+		return
+	}
+
+	directory := filepath.Dir(filePath)
+	if makeOutputDirectory(&directory) != nil {
+		return
+	}
+
+	file.Close()
+	if os.Rename(file.Name(), filePath) != nil {
+		copyFile(file.Name(), filePath, 0644)
+	}
+
+	now := time.Now()
+	os.Chtimes(filePath, now, now)
+}
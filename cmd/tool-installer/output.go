@@ -0,0 +1,189 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Exit code of 'install' if any tool failed, distinct from the exit code 1 of
+// errors that stop tooli before any tool is installed
+const failedToolsExitCode = 3
+
+// Where the results are written with '--output json', nil for the usual text output
+var jsonOutput *os.File
+
+// What happened to a tool during a command
+const (
+	actionInstalled       = "installed"
+	actionUpdated         = "updated"
+	actionUnchanged       = "unchanged"
+	actionSkipped         = "skipped"
+	actionFailed          = "failed"
+	actionCancelled       = "cancelled"
+	actionRemoved         = "removed"
+	actionUpToDate        = "up_to_date"
+	actionUpdateAvailable = "update_available"
+)
+
+// The result for a single tool of 'install', 'check', 'sync' or 'ui' in the JSON output
+type ToolResult struct {
+	Tool       string `json:"tool"`
+	Action     string `json:"action"`
+	OldVersion string `json:"old_version,omitempty"`
+	NewVersion string `json:"new_version,omitempty"`
+	Held       bool   `json:"held,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+func (r ToolResult) GetName() string {
+	return r.Tool
+}
+
+// An entry of 'list' in the JSON output
+type ListResult struct {
+	Tool        string   `json:"tool"`
+	Repository  string   `json:"repository"`
+	Description string   `json:"description"`
+	Version     string   `json:"version,omitempty"`
+	Enabled     bool     `json:"enabled"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// Selects the output format. With 'json', all messages meant for people are
+// printed to stderr instead, so that stdout only holds the JSON document.
+func setOutputFormat(format string) error {
+	switch format {
+	case "text":
+	case "json":
+		jsonOutput = os.Stdout
+		os.Stdout = os.Stderr
+	default:
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("Invalid output format '%s', expected 'text' or 'json'.", format)
+	}
+
+	return nil
+}
+
+// Returns the result of installing a tool from its versions before and after
+func getInstallResult(name string, oldVersion string, newVersion string, err error) ToolResult {
+	result := ToolResult{Tool: name, OldVersion: oldVersion, NewVersion: newVersion}
+
+	switch {
+	case err != nil:
+		result.Action = actionFailed
+		result.NewVersion = ""
+		result.Error = err.Error()
+	case oldVersion == "":
+		result.Action = actionInstalled
+	case oldVersion != newVersion:
+		result.Action = actionUpdated
+	default:
+		result.Action = actionUnchanged
+	}
+
+	return result
+}
+
+// Prints how many tools were installed, updated, removed, up to date, skipped,
+// failed or cancelled, with the names of the tools that changed or failed
+func printSummary(results []ToolResult) {
+	if len(results) == 0 || isQuiet() {
+		return
+	}
+
+	rows := []struct {
+		action    string
+		label     string
+		showNames bool
+	}{
+		{actionInstalled, "Installed", true},
+		{actionUpdated, "Updated", true},
+		{actionRemoved, "Removed", true},
+		{actionUnchanged, "Up to date", false},
+		{actionSkipped, "Skipped", false},
+		{actionFailed, "Failed", true},
+		{actionCancelled, "Cancelled", false},
+	}
+
+	names := make(map[string][]string)
+	for _, result := range results {
+		names[result.Action] = append(names[result.Action], result.Tool)
+	}
+
+	fmt.Println()
+	fmt.Println("Summary:")
+	for _, row := range rows {
+		tools := names[row.action]
+		if len(tools) == 0 && row.action != actionFailed {
+			continue
+		}
+
+		line := fmt.Sprintf("    %-10s  %4d", row.label, len(tools))
+		if row.showNames && len(tools) > 0 {
+			sort.Strings(tools)
+			line += "  " + strings.Join(tools, ", ")
+		}
+		fmt.Println(line)
+	}
+}
+
+// Writes the results as JSON, sorted by the tools' names
+func writeToolResults(results []ToolResult) {
+	if results == nil {
+		results = []ToolResult{}
+	}
+	sort.Sort(ByName[ToolResult]{results})
+
+	writeJsonOutput(results)
+}
+
+func writeJsonOutput(value any) {
+	encoder := json.NewEncoder(jsonOutput)
+	encoder.SetIndent("", "\t")
+	encoder.SetEscapeHTML(false)
+
+	err := encoder.Encode(value)
+	if err != nil {
+		logError("%v", err)
+		os.Exit(1)
+	}
+}
+
+// Writes the results of 'check' as JSON, including the tools that are up to date
+func writeCheckResults(entries []VersionTableEntry, failures []ToolResult, cache *Cache) {
+	results := failures
+	for _, entry := range entries {
+		result := ToolResult{Tool: entry.Name, Action: actionUpToDate, OldVersion: entry.Installed, NewVersion: entry.Available}
+		if !isSameVersion(entry.Installed, entry.Available) {
+			result.Action = actionUpdateAvailable
+			result.Held = cache.Held[entry.Name]
+		}
+		results = append(results, result)
+	}
+
+	writeToolResults(results)
+}
+
+// Writes the tools of 'list' as JSON
+func writeListResults(entries []TableEntry, config *Configuration, cache *Cache) {
+	results := make([]ListResult, len(entries))
+	for i, entry := range entries {
+		tool := config.Tools[entry.Name]
+		results[i] = ListResult{
+			Tool:        entry.Name,
+			Repository:  entry.Link,
+			Description: entry.Description,
+			Version:     cache.Tools[entry.Name],
+			Enabled:     tool.isEnabled(),
+			Tags:        tool.Tags,
+		}
+	}
+
+	writeJsonOutput(results)
+}
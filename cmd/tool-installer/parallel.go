@@ -0,0 +1,47 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"sync"
+)
+
+// How many tools are installed or checked at the same time by default
+const defaultJobs = 4
+
+// Returns the number of parallel jobs, from the command line if given there,
+// otherwise from the configuration
+func (config *Configuration) getJobs(jobs int) int {
+	if jobs > 0 {
+		return jobs
+	}
+
+	if config.Jobs > 0 {
+		return config.Jobs
+	}
+
+	return defaultJobs
+}
+
+// Calls work for every item, with at most jobs calls running at the same time
+func runParallel[T any](items []T, jobs int, work func(T)) {
+	queue := make(chan T)
+
+	var wait sync.WaitGroup
+	for range min(max(jobs, 1), len(items)) {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			for item := range queue {
+				work(item)
+			}
+		}()
+	}
+
+	for _, item := range items {
+		queue <- item
+	}
+	close(queue)
+
+	wait.Wait()
+}
@@ -6,6 +6,8 @@ import (
 	"os"
 	"os/user"
 	"path/filepath"
+	"regexp"
+	"runtime"
 	"strings"
 )
 
@@ -34,6 +36,32 @@ func getCacheFilePath() (string, error) {
 	return filepath.Join(baseDir, "tool-installer", "tool-versions.json"), nil
 }
 
+// Returns the directory from the XDG environment variable or the given
+// directory relative to the home directory
+func getXdgDirectory(variable string, fallback string) (string, error) {
+	if directory := os.Getenv(variable); directory != "" {
+		return directory, nil
+	}
+
+	usr, err := user.Current()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(usr.HomeDir, fallback), nil
+}
+
+// Tools installed in directory mode are extracted completely into their own
+// directory below the data directory
+func getToolDirectory(name string) (string, error) {
+	dataDirectory, err := getXdgDirectory("XDG_DATA_HOME", filepath.Join(".local", "share"))
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dataDirectory, "tool-installer", "tools", name), nil
+}
+
 func getConfigFilePath() (string, error) {
 	baseDir := ""
 
@@ -48,7 +76,88 @@ func getConfigFilePath() (string, error) {
 		baseDir = filepath.Join(usr.HomeDir, ".config")
 	}
 
-	return filepath.Join(baseDir, "tool-installer", "config.json"), nil
+	// A TOML configuration is used if there is no JSON one
+	result := filepath.Join(baseDir, "tool-installer", "config.json")
+	tomlPath := filepath.Join(baseDir, "tool-installer", "config.toml")
+	if _, err := os.Stat(result); os.IsNotExist(err) {
+		if _, err := os.Stat(tomlPath); err == nil {
+			return tomlPath, nil
+		}
+	}
+
+	return result, nil
+}
+
+// Returns the directory in which the gh CLI stores its configuration
+func getGhConfigDirectory() (string, error) {
+	if ghConfigDir := os.Getenv("GH_CONFIG_DIR"); ghConfigDir != "" {
+		return ghConfigDir, nil
+	}
+
+	if xdgConfigHome := os.Getenv("XDG_CONFIG_HOME"); xdgConfigHome != "" {
+		return filepath.Join(xdgConfigHome, "gh"), nil
+	}
+
+	if appData := os.Getenv("AppData"); runtime.GOOS == "windows" && appData != "" {
+		return filepath.Join(appData, "GitHub CLI"), nil
+	}
+
+	usr, err := user.Current()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(usr.HomeDir, ".config", "gh"), nil
+}
+
+// Defaults of the XDG variables, which are often unset, relative to the home directory
+var xdgDefaults = map[string]string{
+	"XDG_DATA_HOME":   filepath.Join(".local", "share"),
+	"XDG_CONFIG_HOME": ".config",
+	"XDG_CACHE_HOME":  ".cache",
+	"XDG_STATE_HOME":  filepath.Join(".local", "state"),
+	"XDG_BIN_HOME":    filepath.Join(".local", "bin"),
+}
+
+// Matches Windows style variables like %LOCALAPPDATA%
+var windowsVariableRegex = regexp.MustCompile(`%([A-Za-z_][A-Za-z0-9_()]*)%`)
+
+// Matches Unix style variables like $HOME or ${XDG_DATA_HOME}
+var variableRegex = regexp.MustCompile(`\$(?:\{([A-Za-z_][A-Za-z0-9_]*)\}|([A-Za-z_][A-Za-z0-9_]*))`)
+
+// Expands environment variables like $HOME or ${XDG_DATA_HOME} and a leading
+// '~' in paths from the configuration. Unset XDG variables use their defaults,
+// other unset or empty variables are left as they are instead of turning e.g.
+// '$TOOLS/bin' into '/bin'. On Windows, variables like %USERPROFILE% are
+// expanded as well.
+func expandPath(path string) string {
+	if runtime.GOOS == "windows" {
+		path = windowsVariableRegex.ReplaceAllStringFunc(path, func(match string) string {
+			if value := os.Getenv(match[1 : len(match)-1]); value != "" {
+				return value
+			}
+			return match
+		})
+	}
+
+	expanded := variableRegex.ReplaceAllStringFunc(path, func(match string) string {
+		groups := variableRegex.FindStringSubmatch(match)
+		variable := groups[1] + groups[2]
+
+		if value := os.Getenv(variable); value != "" {
+			return value
+		}
+
+		if fallback, found := xdgDefaults[variable]; found {
+			if directory, err := getXdgDirectory(variable, fallback); err == nil {
+				return directory
+			}
+		}
+
+		return match
+	})
+
+	return replaceTildePath(expanded)
 }
 
 func replaceTildePath(path string) string {
@@ -57,7 +166,7 @@ func replaceTildePath(path string) string {
 
 	if path == "~" {
 		return dir
-	} else if strings.HasPrefix(path, "~/") {
+	} else if strings.HasPrefix(path, "~/") || runtime.GOOS == "windows" && strings.HasPrefix(path, `~\`) {
 		return filepath.Join(dir, path[2:])
 	} else {
 		return path
@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"slices"
+	"strings"
+)
+
+// A subset of the tools, e.g. for a single machine, selected with --profile,
+// the TOOLI_PROFILE environment variable or by matching the host name
+type Profile struct {
+	Hosts                 []string `json:"hosts,omitempty"`
+	Tools                 []string `json:"tools,omitempty"`
+	InstallationDirectory string   `json:"install_dir,omitempty"`
+}
+
+// Set by the global --profile option
+var profileOverride string
+
+// Returns the name of the profile to use, or nothing if all tools are used
+func (config *Configuration) getProfileName() string {
+	if profileOverride != "" {
+		return profileOverride
+	}
+
+	if name := os.Getenv("TOOLI_PROFILE"); name != "" {
+		return name
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || len(config.Profiles) == 0 {
+		return ""
+	}
+	hostname = strings.ToLower(hostname)
+
+	for _, name := range slices.Sorted(maps.Keys(config.Profiles)) {
+		for _, pattern := range config.Profiles[name].Hosts {
+			matched, _ := path.Match(strings.ToLower(pattern), hostname)
+			if matched {
+				return name
+			}
+		}
+	}
+
+	return ""
+}
+
+// Returns the tools selected by a profile, tags are expanded
+func (config *Configuration) getProfileTools(name string) ([]string, error) {
+	tools, _, err := config.expandToolSpecs(config.Profiles[name].Tools)
+	if err != nil {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return nil, fmt.Errorf("Invalid tools of profile '%s': %v", name, strings.TrimSuffix(err.Error(), "."))
+	}
+
+	for _, tool := range tools {
+		if _, found := config.Tools[tool]; !found {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return nil, fmt.Errorf("The profile '%s' contains the tool '%s', which is not in the configuration", name, tool)
+		}
+	}
+
+	return tools, nil
+}
+
+// Restricts the configuration to the tools and installation directory of the
+// selected profile
+func (config *Configuration) applyProfile() error {
+	name := config.getProfileName()
+	if name == "" {
+		return nil
+	}
+
+	profile, found := config.Profiles[name]
+	if !found {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("The profile '%s' does not exist", name)
+	}
+
+	if profile.InstallationDirectory != "" {
+		config.InstallationDirectory = expandPath(profile.InstallationDirectory)
+	}
+
+	if len(profile.Tools) == 0 {
+		return nil
+	}
+
+	names, err := config.getProfileTools(name)
+	if err != nil {
+		return err
+	}
+
+	tools := make(map[string]Tool, len(names))
+	for _, tool := range names {
+		tools[tool] = config.Tools[tool]
+	}
+	config.Tools = tools
+
+	return nil
+}
@@ -0,0 +1,249 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// How often the progress display is redrawn at most
+const progressInterval = 100 * time.Millisecond
+
+// Shows one line per running download, redrawn in place. It is only shown on
+// a terminal, otherwise the bars do nothing.
+type ProgressDisplay struct {
+	mutex    sync.Mutex
+	enabled  bool
+	bars     []*ProgressBar
+	lines    int
+	lastDraw time.Time
+	// The terminal while the output is captured, nil otherwise
+	output *os.File
+}
+
+type ProgressBar struct {
+	display *ProgressDisplay
+	name    string
+	total   int64
+	current int64
+	// Bytes that were already downloaded before, not counted for the speed
+	resumed int64
+	start   time.Time
+}
+
+// The body of a response together with its Content-Length, -1 if unknown
+type ResponseBody struct {
+	io.ReadCloser
+	contentLength int64
+	// Whether only the rest of the content after the requested offset is sent
+	partial bool
+}
+
+type progressReader struct {
+	reader io.Reader
+	bar    *ProgressBar
+}
+
+func newProgressDisplay() *ProgressDisplay {
+	stat, err := os.Stdout.Stat()
+	enabled := err == nil && stat.Mode()&os.ModeCharDevice != 0 && os.Getenv("TERM") != "dumb" && !isQuiet()
+
+	return &ProgressDisplay{enabled: enabled}
+}
+
+// Returns the Content-Length of a body opened by the downloader, -1 if unknown
+func getContentLength(body io.Reader) int64 {
+	if response, ok := body.(*ResponseBody); ok {
+		return response.contentLength
+	}
+
+	return -1
+}
+
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	value := float64(bytes) / unit
+	for _, suffix := range []string{"KiB", "MiB", "GiB"} {
+		if value < unit {
+			return fmt.Sprintf("%.1f %s", value, suffix)
+		}
+		value /= unit
+	}
+
+	return fmt.Sprintf("%.1f TiB", value)
+}
+
+// Adds a bar for a download of the given size, -1 if it is unknown, that
+// continues after the bytes that were already downloaded
+func (display *ProgressDisplay) newBar(name string, resumed int64, total int64) *ProgressBar {
+	bar := &ProgressBar{display: display, name: name, total: total, current: resumed, resumed: resumed, start: time.Now()}
+
+	display.mutex.Lock()
+	defer display.mutex.Unlock()
+
+	display.bars = append(display.bars, bar)
+	display.draw(true)
+
+	return bar
+}
+
+// Returns a reader that advances the bar with every read
+func (bar *ProgressBar) wrap(reader io.Reader) io.Reader {
+	return &progressReader{reader: reader, bar: bar}
+}
+
+func (reader *progressReader) Read(buffer []byte) (int, error) {
+	n, err := reader.reader.Read(buffer)
+	if n > 0 {
+		reader.bar.advance(int64(n))
+	}
+
+	return n, err
+}
+
+func (bar *ProgressBar) advance(n int64) {
+	display := bar.display
+
+	display.mutex.Lock()
+	defer display.mutex.Unlock()
+
+	bar.current += n
+	display.draw(false)
+}
+
+// Removes the bar from the display once its download is complete or failed
+func (bar *ProgressBar) finish() {
+	display := bar.display
+
+	display.mutex.Lock()
+	defer display.mutex.Unlock()
+
+	for i, other := range display.bars {
+		if other == bar {
+			display.bars = append(display.bars[:i], display.bars[i+1:]...)
+			break
+		}
+	}
+	display.draw(true)
+}
+
+func (bar *ProgressBar) String() string {
+	elapsed := time.Since(bar.start).Seconds()
+	speed := 0.0
+	if elapsed > 0 {
+		speed = float64(bar.current-bar.resumed) / elapsed
+	}
+
+	if bar.total <= 0 {
+		return fmt.Sprintf("%-20s %10s  %10s/s", bar.name, formatBytes(bar.current), formatBytes(int64(speed)))
+	}
+
+	const width = 30
+	fraction := min(float64(bar.current)/float64(bar.total), 1)
+	filled := int(fraction * width)
+
+	eta := "--"
+	if speed > 0 {
+		remaining := time.Duration(float64(bar.total-bar.current) / speed * float64(time.Second))
+		eta = remaining.Round(time.Second).String()
+	}
+
+	return fmt.Sprintf("%-20s [%s%s] %3.0f%%  %s / %s  %s/s  ETA %s", bar.name, strings.Repeat("=", filled), strings.Repeat(" ", width-filled),
+		100*fraction, formatBytes(bar.current), formatBytes(bar.total), formatBytes(int64(speed)), eta)
+}
+
+// Replaces the previously drawn lines with the current bars, at most every
+// progressInterval unless forced. The caller holds the mutex.
+func (display *ProgressDisplay) draw(force bool) {
+	if !display.enabled || (!force && time.Since(display.lastDraw) < progressInterval) || (display.lines == 0 && len(display.bars) == 0) {
+		return
+	}
+
+	display.render("")
+}
+
+// Prints the text in place of the bars and draws them again below it. The
+// caller holds the mutex.
+func (display *ProgressDisplay) render(text string) {
+	display.lastDraw = time.Now()
+
+	var output strings.Builder
+	if display.lines > 0 {
+		fmt.Fprintf(&output, "\x1b[%dF", display.lines)
+	}
+	output.WriteString("\x1b[J")
+	output.WriteString(text)
+	for _, bar := range display.bars {
+		output.WriteString(bar.String())
+		output.WriteString("\n")
+	}
+	display.lines = len(display.bars)
+
+	if display.output != nil {
+		display.output.WriteString(output.String())
+	} else {
+		fmt.Print(output.String())
+	}
+}
+
+// Sends everything that is printed to stdout through the display while tools
+// are installed in parallel, so that messages appear above the bars instead
+// of being overwritten by them. The returned function restores stdout.
+func (display *ProgressDisplay) captureOutput() func() {
+	if !display.enabled {
+		return func() {}
+	}
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return func() {}
+	}
+
+	display.mutex.Lock()
+	display.output = os.Stdout
+	display.mutex.Unlock()
+	os.Stdout = writer
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		buffered := bufio.NewReader(reader)
+		for {
+			line, err := buffered.ReadString('\n')
+			if line != "" {
+				if !strings.HasSuffix(line, "\n") {
+					line += "\n"
+				}
+
+				display.mutex.Lock()
+				display.render(line)
+				display.mutex.Unlock()
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	return func() {
+		writer.Close()
+		<-done
+		reader.Close()
+
+		display.mutex.Lock()
+		os.Stdout = display.output
+		display.output = nil
+		display.mutex.Unlock()
+	}
+}
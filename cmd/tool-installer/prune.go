@@ -0,0 +1,139 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Returns the names of the files in the installation directory that belong
+// to the configured tools, from the files recorded at installation and the
+// configured binaries for tools installed before files were recorded
+func getConfiguredFileNames(tools map[string]Tool, cache *Cache) map[string]bool {
+	result := make(map[string]bool)
+
+	for name, tool := range tools {
+		addInstalledFileNames(result, cache.Installed[name].Files)
+
+		for _, installedName := range getBinaryFileNames(tool) {
+			result[installedName] = true
+		}
+	}
+
+	return result
+}
+
+// Returns the names of the configured binaries of a tool in the installation
+// directory, skipping patterns whose installed name is unknown
+func getBinaryFileNames(tool Tool) []string {
+	var result []string
+
+	for _, binary := range tool.Binaries {
+		installedName := binary.RenameTo
+		if installedName == "" {
+			if strings.ContainsAny(binary.Name, "*?[") {
+				continue
+			}
+			installedName = path.Base(binary.Name)
+		}
+		if runtime.GOOS == "windows" {
+			installedName = addExeSuffix(installedName)
+		}
+		result = append(result, installedName)
+	}
+
+	return result
+}
+
+// Lists the files in the installation directory and the tool directories that
+// do not belong to any configured tool, and the cache entries of tools that
+// are no longer configured. With '--yes', they are deleted.
+func pruneFiles(configLocation *string) {
+	config, err := getConfig(*configLocation)
+	if err != nil {
+		printConfigError(err)
+		os.Exit(1)
+	}
+
+	// A profile restricts the tools, but files of the other tools are kept
+	allTools, err := loadConfiguration(*configLocation)
+	if err != nil {
+		printConfigError(err)
+		os.Exit(1)
+	}
+
+	cache, err := getCache()
+	if err != nil {
+		logError("Failed to obtain cache. Message: %v", err)
+		os.Exit(1)
+	}
+
+	var paths []string
+	for _, file := range getUnknownFiles(config.InstallationDirectory, getConfiguredFileNames(allTools.Tools, &cache)) {
+		paths = append(paths, filepath.Join(config.InstallationDirectory, file))
+	}
+
+	toolsDirectory, err := getToolDirectory("")
+	if err == nil {
+		known := make(map[string]bool)
+		for name := range allTools.Tools {
+			known[name] = true
+		}
+		for _, directory := range getUnknownFiles(toolsDirectory, known) {
+			paths = append(paths, filepath.Join(toolsDirectory, directory))
+		}
+	}
+
+	var removedTools []string
+	for name := range cache.Tools {
+		if _, found := allTools.Tools[name]; !found {
+			removedTools = append(removedTools, name)
+		}
+	}
+	sort.Strings(removedTools)
+
+	if len(paths) == 0 && len(removedTools) == 0 {
+		fmt.Println("Nothing to prune.")
+		return
+	}
+
+	for _, file := range paths {
+		fmt.Println(file)
+	}
+	for _, name := range removedTools {
+		fmt.Printf("Cache entry of '%s'\n", name)
+	}
+
+	if !assumeYes {
+		fmt.Println("\nThese do not belong to any configured tool. Run 'tooli prune --yes' to delete them.")
+		return
+	}
+
+	for _, file := range paths {
+		err = removeDirectoryInUse(file)
+		if err != nil {
+			logError("%v", err)
+			os.Exit(1)
+		}
+	}
+
+	for _, name := range removedTools {
+		delete(cache.Tools, name)
+		delete(cache.Installed, name)
+		delete(cache.Held, name)
+	}
+
+	err = cache.writeCache()
+	if err != nil {
+		logError("%v", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\nDeleted %d file(s) and %d cache entries.\n", len(paths), len(removedTools))
+}
@@ -0,0 +1,96 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// The request budget of a host as reported by the X-RateLimit-* headers
+type RateLimit struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+func parseRateLimit(header http.Header) (RateLimit, bool) {
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return RateLimit{}, false
+	}
+
+	limit, _ := strconv.Atoi(header.Get("X-RateLimit-Limit"))
+
+	reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return RateLimit{}, false
+	}
+
+	return RateLimit{Limit: limit, Remaining: remaining, Reset: time.Unix(reset, 0)}, true
+}
+
+func (client *Downloader) updateRateLimit(resp *http.Response) {
+	if rateLimit, found := parseRateLimit(resp.Header); found {
+		client.mutex.Lock()
+		client.rateLimits[resp.Request.URL.Host] = rateLimit
+		client.mutex.Unlock()
+	}
+}
+
+func (client *Downloader) getRateLimit(host string) (RateLimit, bool) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	rateLimit, found := client.rateLimits[host]
+	return rateLimit, found
+}
+
+func formatReset(reset time.Time) string {
+	wait := time.Until(reset).Round(time.Second)
+	if wait < 0 {
+		wait = 0
+	}
+
+	return fmt.Sprintf("%s (in %v)", reset.Format("15:04:05"), wait)
+}
+
+// Returned when a host has no requests left
+type RateLimitError struct {
+	host      string
+	rateLimit RateLimit
+}
+
+func (err *RateLimitError) Error() string {
+	return fmt.Sprintf("The rate limit of %s is exhausted, it resets at %s. To increase the number of requests you can make, set the 'GITHUB_TOKEN' environment variable.", err.host, formatReset(err.rateLimit.Reset))
+}
+
+func rateLimitExceededError(host string, rateLimit RateLimit) error {
+	return &RateLimitError{host: host, rateLimit: rateLimit}
+}
+
+// Fails without sending a request if the host is known to have no requests left
+func (client *Downloader) checkRateLimit(host string) error {
+	rateLimit, found := client.getRateLimit(host)
+	if found && rateLimit.Remaining == 0 && time.Now().Before(rateLimit.Reset) {
+		return rateLimitExceededError(host, rateLimit)
+	}
+
+	return nil
+}
+
+// Fails if GitHub's remaining request budget is known to be smaller than the
+// number of requests that are still needed, so a run does not stop halfway
+func (client *Downloader) checkBudget(pendingRequests int) error {
+	host := githubApiHost
+
+	rateLimit, found := client.getRateLimit(host)
+	if !found || !time.Now().Before(rateLimit.Reset) || rateLimit.Remaining >= pendingRequests {
+		return nil
+	}
+
+	//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+	return fmt.Errorf("Only %d requests to %s are left but %d are needed, the limit resets at %s. To increase the number of requests you can make, set the 'GITHUB_TOKEN' environment variable.", rateLimit.Remaining, host, pendingRequests, formatReset(rateLimit.Reset))
+}
@@ -0,0 +1,241 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Reads the configuration file as a generic document, without its included
+// files, so that single values can be changed and written back
+func readRawConfiguration(path string) (map[string]any, error) {
+	filePath := replaceTildePath(path)
+	if isRemoteLocation(path) {
+		var err error
+		filePath, err = resolveRemoteLocation(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeRawConfiguration(filePath, content)
+}
+
+// Checks that a generic document still decodes into a configuration
+func checkRawConfiguration(raw map[string]any) error {
+	content, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+
+	var config Configuration
+	return json.Unmarshal(content, &config)
+}
+
+// Writes a generic document back in the format of the file. Comments and the
+// order of the keys are not preserved.
+func writeRawConfiguration(path string, raw map[string]any) error {
+	if isRemoteLocation(path) {
+		return errRemoteConfiguration(path)
+	}
+
+	err := checkRawConfiguration(raw)
+	if err != nil {
+		return err
+	}
+
+	var result bytes.Buffer
+	if isTomlFile(path) {
+		encoder := toml.NewEncoder(&result)
+		encoder.Indent = ""
+		err = encoder.Encode(raw)
+	} else {
+		encoder := json.NewEncoder(&result)
+		encoder.SetIndent("", "\t")
+		encoder.SetEscapeHTML(false)
+		err = encoder.Encode(raw)
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(replaceTildePath(path), result.Bytes(), 0644)
+}
+
+// Parses a value given on the command line as JSON, so that numbers, booleans,
+// lists and objects can be set. Anything else is taken as a plain string.
+func parseRawValue(text string) any {
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+
+	var value any
+	if decoder.Decode(&value) != nil || decoder.More() {
+		return text
+	}
+
+	return normalizeNumbers(value)
+}
+
+// Turns json.Number into int64 or float64, which both encoders understand
+func normalizeNumbers(value any) any {
+	switch value := value.(type) {
+	case json.Number:
+		if number, err := value.Int64(); err == nil {
+			return number
+		}
+		number, _ := value.Float64()
+		return number
+	case []any:
+		for i := range value {
+			value[i] = normalizeNumbers(value[i])
+		}
+	case map[string]any:
+		for key := range value {
+			value[key] = normalizeNumbers(value[key])
+		}
+	}
+
+	return value
+}
+
+// Returns the value at a dotted path like 'tools.ripgrep.linux_asset', list
+// elements are addressed by their index
+func getRawValue(raw any, path string) (any, error) {
+	current := raw
+	for _, key := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]any:
+			value, found := node[key]
+			if !found {
+				//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+				return nil, fmt.Errorf("The key '%s' does not exist in the configuration.", path)
+			}
+			current = value
+		case []any:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(node) {
+				//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+				return nil, fmt.Errorf("The key '%s' does not exist in the configuration.", path)
+			}
+			current = node[index]
+		default:
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return nil, fmt.Errorf("The key '%s' does not exist in the configuration.", path)
+		}
+	}
+
+	return current, nil
+}
+
+// Sets the value at a dotted path, missing objects along the way are created
+func setRawValue(raw map[string]any, path string, value any) error {
+	keys := strings.Split(path, ".")
+
+	var current any = raw
+	for i, key := range keys {
+		last := i == len(keys)-1
+
+		switch node := current.(type) {
+		case map[string]any:
+			if last {
+				node[key] = value
+				return nil
+			}
+			if _, found := node[key]; !found {
+				node[key] = make(map[string]any)
+			}
+			current = node[key]
+		case []any:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(node) {
+				//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+				return fmt.Errorf("'%s' is not a valid index of '%s'.", key, strings.Join(keys[:i], "."))
+			}
+			if last {
+				node[index] = value
+				return nil
+			}
+			current = node[index]
+		default:
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return fmt.Errorf("'%s' is not an object or list.", strings.Join(keys[:i], "."))
+		}
+	}
+
+	return nil
+}
+
+// Adds tools to the 'tools' of the configuration file, which must not
+// contain them yet
+func addRawTools(raw map[string]any, tools map[string]Tool) error {
+	rawTools, ok := raw["tools"].(map[string]any)
+	if !ok {
+		if raw["tools"] != nil {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return errors.New("The 'tools' of the configuration are not an object.")
+		}
+		rawTools = make(map[string]any)
+		raw["tools"] = rawTools
+	}
+
+	for name, tool := range tools {
+		if _, found := rawTools[name]; found {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return fmt.Errorf("Tool '%s' already exists in the configuration.", name)
+		}
+
+		content, err := json.Marshal(tool)
+		if err != nil {
+			return err
+		}
+
+		var rawTool map[string]any
+		err = json.Unmarshal(content, &rawTool)
+		if err != nil {
+			return err
+		}
+		rawTools[name] = rawTool
+	}
+
+	return nil
+}
+
+// Returns the entry of a tool in the configuration file, included files are
+// not searched
+func getRawTool(raw map[string]any, name string) (map[string]any, error) {
+	rawTools, _ := raw["tools"].(map[string]any)
+	rawTool, found := rawTools[name].(map[string]any)
+	if !found {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return nil, fmt.Errorf("Tool '%s' is defined in an included file, please change it there.", name)
+	}
+
+	return rawTool, nil
+}
+
+// Decodes the entry of a tool from the configuration file
+func decodeRawTool(rawTool map[string]any) (Tool, error) {
+	var result Tool
+
+	content, err := json.Marshal(rawTool)
+	if err != nil {
+		return result, err
+	}
+
+	err = json.Unmarshal(content, &result)
+
+	return result, err
+}
@@ -0,0 +1,352 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// How long fetching a remote configuration may take
+const remoteConfigTimeout = 30 * time.Second
+
+// Whether the configuration location is a URL instead of a local file
+func isRemoteLocation(location string) bool {
+	for _, prefix := range []string{"http://", "https://", "git+", "git@", "ssh://"} {
+		if strings.HasPrefix(location, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Whether the remote location is a git repository, given as 'git+<url>', as
+// an SSH URL or as a URL ending in '.git'. The file inside the repository can
+// be selected with a fragment, e.g. 'git+https://example.com/tools.git#team.toml'.
+func isGitLocation(location string) bool {
+	repository, _, _ := strings.Cut(location, "#")
+
+	return strings.HasPrefix(location, "git+") || strings.HasPrefix(location, "git@") ||
+		strings.HasPrefix(location, "ssh://") || strings.HasSuffix(repository, ".git")
+}
+
+func errRemoteConfiguration(location string) error {
+	//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+	return fmt.Errorf("The configuration '%s' is remote and cannot be changed by tooli, add local tools to the 'config.d' directory of your local configuration instead.", location)
+}
+
+// Returns the path in the cache directory where the remote location is kept
+func getRemoteCachePath(location string) (string, error) {
+	cacheFilePath, err := getCacheFilePath()
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256([]byte(location))
+	name := hex.EncodeToString(hash[:8])
+
+	if !isGitLocation(location) {
+		if strings.HasSuffix(strings.ToLower(path.Ext(location)), ".toml") {
+			name += ".toml"
+		} else {
+			name += ".json"
+		}
+	}
+
+	return filepath.Join(filepath.Dir(cacheFilePath), "remote", name), nil
+}
+
+// Downloads the configuration into the cache, the previously cached content is
+// only replaced by a valid configuration
+func fetchRemoteFile(location string, filePath string) error {
+	req, err := http.NewRequest(http.MethodGet, location, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("User-Agent", userAgent)
+
+	client := http.Client{Timeout: remoteConfigTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("Got status code '%v'", resp.StatusCode)
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	_, err = decodeRawConfiguration(filePath, content)
+	if err != nil {
+		return err
+	}
+
+	directory := filepath.Dir(filePath)
+	err = makeOutputDirectory(&directory)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filePath, content, 0644)
+}
+
+func runGit(arguments ...string) error {
+	output, err := exec.Command("git", arguments...).CombinedOutput()
+	if err != nil {
+		message := strings.TrimSpace(string(output))
+		if message == "" {
+			return err
+		}
+		return errors.New(message)
+	}
+
+	return nil
+}
+
+// Clones the repository into the cache, or updates the existing clone
+func fetchRemoteRepository(repository string, directory string) error {
+	if _, err := os.Stat(filepath.Join(directory, ".git")); err == nil {
+		return runGit("-C", directory, "pull", "--ff-only", "--quiet")
+	}
+
+	parent := filepath.Dir(directory)
+	err := makeOutputDirectory(&parent)
+	if err != nil {
+		return err
+	}
+
+	return runGit("clone", "--depth", "1", "--quiet", "--", repository, directory)
+}
+
+// Returns the configuration file inside a cloned repository, by default
+// 'config.json' or 'config.toml' at its top level
+func getRepositoryConfigFile(directory string, file string) (string, error) {
+	if file != "" {
+		if !filepath.IsLocal(filepath.FromSlash(file)) {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return "", fmt.Errorf("The file '%s' is not inside the repository", file)
+		}
+		return filepath.Join(directory, filepath.FromSlash(file)), nil
+	}
+
+	for _, name := range []string{"config.json", "config.toml"} {
+		result := filepath.Join(directory, name)
+		if _, err := os.Stat(result); err == nil {
+			return result, nil
+		}
+	}
+
+	//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+	return "", errors.New("The repository has neither a 'config.json' nor a 'config.toml', select the file with '#path/in/repository'")
+}
+
+// Fetches a remote configuration into the cache and returns the path of the
+// cached file. If it cannot be fetched, the previously cached copy is used.
+func resolveRemoteLocation(location string) (string, error) {
+	if strings.HasPrefix(location, "http://") {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return "", fmt.Errorf("The configuration '%s' is fetched without encryption, use 'https://' instead", location)
+	}
+
+	cachePath, err := getRemoteCachePath(location)
+	if err != nil {
+		return "", err
+	}
+
+	_, statErr := os.Stat(cachePath)
+	cached := statErr == nil
+
+	if offlineMode {
+		if !cached {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return "", fmt.Errorf("The configuration '%s' was never fetched and is not available offline", location)
+		}
+		if isGitLocation(location) {
+			_, file, _ := strings.Cut(location, "#")
+			return getRepositoryConfigFile(cachePath, file)
+		}
+		return cachePath, nil
+	}
+
+	if isGitLocation(location) {
+		repository, file, _ := strings.Cut(strings.TrimPrefix(location, "git+"), "#")
+		if strings.HasPrefix(repository, "-") {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return "", fmt.Errorf("Invalid repository '%s'", repository)
+		}
+		err = fetchRemoteRepository(repository, cachePath)
+		if err != nil && cached {
+			logWarning("Could not update the configuration '%s', using the cached copy: %v.", location, err)
+			err = nil
+		}
+		if err == nil {
+			return getRepositoryConfigFile(cachePath, file)
+		}
+	} else {
+		err = fetchRemoteFile(location, cachePath)
+		if err != nil && cached {
+			logWarning("Could not fetch the configuration '%s', using the cached copy: %v.", location, err)
+			err = nil
+		}
+		if err == nil {
+			return cachePath, nil
+		}
+	}
+
+	//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+	return "", fmt.Errorf("Could not fetch the configuration '%s': %v", location, err)
+}
+
+// The entries of a remote configuration that are allowed without trusting
+// it. Everything else, e.g. directories, tokens, proxies or commands, could
+// change files, run commands or send secrets of the local machine elsewhere.
+var (
+	remoteConfigurationEntries = []string{
+		"schema_version", "exclude_assets", "keep_versions", "require_trust", "jobs", "retry",
+		"include", "profiles", "defaults", "headers", "tools",
+	}
+	remoteDefaultsEntries = []string{
+		"host", "linux_asset", "windows_asset", "asset_prefix", "headers", "tags", "allow_prerelease",
+		"prefer_static", "timeout", "checksum_policy", "install_mode",
+	}
+	remoteToolEntries = []string{
+		"binaries", "host", "owner", "repository", "linux_asset", "windows_asset", "asset_prefix",
+		"url_template", "version_url", "version_regex", "oci_image", "oci_tag", "gist", "gist_revision",
+		"headers", "description", "tags", "enabled", "version", "version_constraint", "allow_prerelease",
+		"prefer_static", "timeout", "checksum_policy", "ignore_versions", "minisign_pubkey", "gpg_key",
+		"cosign_pubkey", "cosign_identity", "cosign_issuer", "attestation", "sha256", "completions",
+		"man_pages", "install_mode",
+	}
+)
+
+// Returns the JSON names of the entries of the struct that are set but not
+// in the allowed list
+func getDisallowedEntries(value any, allowed []string) []string {
+	var result []string
+
+	structValue := reflect.ValueOf(value)
+	for i := 0; i < structValue.NumField(); i++ {
+		name, _, _ := strings.Cut(structValue.Type().Field(i).Tag.Get("json"), ",")
+		if name == "" || slices.Contains(allowed, name) || structValue.Field(i).IsZero() {
+			continue
+		}
+		result = append(result, name)
+	}
+
+	return result
+}
+
+// Returns the entries of a configuration that need trust, i.e. all entries
+// that are not allowed for remote configurations and headers that expand
+// environment variables
+func getPrivilegedEntries(config *Configuration) []string {
+	var result []string
+
+	for _, name := range getDisallowedEntries(*config, remoteConfigurationEntries) {
+		result = append(result, fmt.Sprintf("'%s'", name))
+	}
+
+	for _, host := range slices.Sorted(maps.Keys(config.Headers)) {
+		if hasEnvironmentHeader(config.Headers[host]) {
+			result = append(result, fmt.Sprintf("'headers.%s'", host))
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(config.Profiles)) {
+		if config.Profiles[name].InstallationDirectory != "" {
+			result = append(result, fmt.Sprintf("'install_dir' of profile '%s'", name))
+		}
+	}
+
+	if config.Defaults != nil {
+		for _, name := range getDisallowedEntries(*config.Defaults, remoteDefaultsEntries) {
+			result = append(result, fmt.Sprintf("'%s' of 'defaults'", name))
+		}
+		if hasEnvironmentHeader(config.Defaults.Headers) {
+			result = append(result, "'headers' of 'defaults'")
+		}
+	}
+
+	for _, name := range config.getToolNames() {
+		tool := config.Tools[name]
+		for _, entry := range getPrivilegedToolEntries(&tool) {
+			result = append(result, fmt.Sprintf("%s of tool '%s'", entry, name))
+		}
+	}
+
+	return result
+}
+
+// Returns the entries of a tool that need trust, also used for the tools of
+// the downloaded catalog
+func getPrivilegedToolEntries(tool *Tool) []string {
+	var result []string
+
+	for _, entry := range getDisallowedEntries(*tool, remoteToolEntries) {
+		result = append(result, fmt.Sprintf("'%s'", entry))
+	}
+	if hasEnvironmentHeader(tool.Headers) {
+		result = append(result, "'headers'")
+	}
+
+	// Binaries are installed under their name in the installation directory
+	for _, binary := range tool.Binaries {
+		if binary.RenameTo != "" && (binary.RenameTo != filepath.Base(binary.RenameTo) || !filepath.IsLocal(binary.RenameTo)) {
+			result = append(result, fmt.Sprintf("'rename_to' '%s'", binary.RenameTo))
+		}
+	}
+
+	return result
+}
+
+func hasEnvironmentHeader(headers map[string]string) bool {
+	for _, value := range headers {
+		if strings.Contains(value, "$") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// A remote configuration may only use the entries that need trust once its
+// location was trusted, as anyone who can change it would otherwise control
+// the local machine
+func checkRemoteConfiguration(location string, config *Configuration) error {
+	entries := getPrivilegedEntries(config)
+	if len(entries) == 0 {
+		return nil
+	}
+
+	cache, err := getCache()
+	if err != nil {
+		return err
+	}
+
+	if cache.Trusted[location] {
+		return nil
+	}
+
+	//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+	return fmt.Errorf("The remote configuration '%s' uses %s, which can run commands, read secrets or change files on this machine. Review it and run 'tooli trust %s' to allow it.", location, strings.Join(entries, ", "), location)
+}
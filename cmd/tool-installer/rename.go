@@ -0,0 +1,172 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"runtime"
+)
+
+// Replaces the tool's name in the 'tools' lists of all profiles
+func renameRawProfileTools(raw map[string]any, oldName string, newName string) {
+	profiles, _ := raw["profiles"].(map[string]any)
+	for _, entry := range profiles {
+		profile, _ := entry.(map[string]any)
+		tools, _ := profile["tools"].([]any)
+		for i, tool := range tools {
+			if tool == oldName {
+				tools[i] = newName
+			}
+		}
+	}
+}
+
+// Sets 'rename_to' of the binaries that are installed under the tool's old
+// name to the new name, returns the old and new file names
+func renameRawBinaries(rawTool map[string]any, oldName string, newName string) map[string]string {
+	result := make(map[string]string)
+
+	binaries, _ := rawTool["binaries"].([]any)
+	for _, entry := range binaries {
+		binary, _ := entry.(map[string]any)
+		name, _ := binary["name"].(string)
+		renameTo, _ := binary["rename_to"].(string)
+
+		installedName := renameTo
+		if installedName == "" {
+			installedName = path.Base(name)
+		}
+		if installedName != oldName {
+			continue
+		}
+
+		binary["rename_to"] = newName
+		if runtime.GOOS == "windows" {
+			result[addExeSuffix(oldName)] = addExeSuffix(newName)
+		} else {
+			result[oldName] = newName
+		}
+	}
+
+	return result
+}
+
+// Renames the installed files and updates their entries in the cache
+func renameInstalledFiles(installed *InstalledTool, files map[string]string, installDirectory string) error {
+	for i, file := range installed.Files {
+		newFile, found := files[file]
+		if !found {
+			continue
+		}
+
+		err := os.Rename(getInstalledFilePath(installDirectory, file), getInstalledFilePath(installDirectory, newFile))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		installed.Files[i] = newFile
+		if hash, found := installed.FileHashes[file]; found {
+			delete(installed.FileHashes, file)
+			installed.FileHashes[newFile] = hash
+		}
+	}
+
+	return nil
+}
+
+// Renames a tool in the configuration, the cache and the store. With
+// renameBinaries, binaries named after the tool are renamed as well.
+func renameTool(configLocation *string, oldName string, newName string, renameBinaries bool) error {
+	config, err := loadConfiguration(*configLocation)
+	if err != nil {
+		return err
+	}
+
+	if _, found := config.Tools[oldName]; !found {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("Tool '%s' not found in configuration.", oldName)
+	}
+
+	if _, found := config.Tools[newName]; found {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("Tool '%s' already exists in the configuration.", newName)
+	}
+
+	raw, err := readRawConfiguration(*configLocation)
+	if err != nil {
+		return err
+	}
+
+	rawTool, err := getRawTool(raw, oldName)
+	if err != nil {
+		return err
+	}
+
+	var files map[string]string
+	if renameBinaries {
+		files = renameRawBinaries(rawTool, oldName, newName)
+	}
+
+	rawTools := raw["tools"].(map[string]any)
+	rawTools[newName] = rawTool
+	delete(rawTools, oldName)
+	renameRawProfileTools(raw, oldName, newName)
+
+	err = writeRawConfiguration(*configLocation, raw)
+	if err != nil {
+		return err
+	}
+
+	cache, err := getCache()
+	if err != nil {
+		return err
+	}
+
+	if version, found := cache.Tools[oldName]; found {
+		cache.Tools[newName] = version
+		delete(cache.Tools, oldName)
+	}
+
+	if installed, found := cache.Installed[oldName]; found {
+		err = renameInstalledFiles(&installed, files, config.InstallationDirectory)
+		if err != nil {
+			logWarning("Could not rename the installed binaries: %v.", err)
+		}
+
+		cache.Installed[newName] = installed
+		delete(cache.Installed, oldName)
+	}
+
+	if cache.Held[oldName] {
+		cache.Held[newName] = true
+		delete(cache.Held, oldName)
+	}
+
+	err = cache.writeCache()
+	if err != nil {
+		return err
+	}
+
+	oldStore, err := getStoreDirectory(oldName)
+	if err != nil {
+		return err
+	}
+	newStore, err := getStoreDirectory(newName)
+	if err != nil {
+		return err
+	}
+	err = os.Rename(oldStore, newStore)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		logWarning("Could not rename the stored versions of tool '%s': %v.", oldName, err)
+	}
+
+	fmt.Printf("Renamed tool '%s' to '%s'.\n", oldName, newName)
+	for oldFile, newFile := range files {
+		fmt.Printf("Renamed binary '%s' to '%s'.\n", oldFile, newFile)
+	}
+
+	return nil
+}
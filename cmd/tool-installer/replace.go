@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Suffix of installed files that were moved aside because they were in use
+const replacedFileSuffix = ".tooli-old"
+
+// How many moved aside copies of the same file can exist at the same time
+const maxReplacedFiles = 100
+
+// Windows does not allow overwriting or deleting an executable while it is
+// running, but it allows renaming it. So a file that cannot be deleted is
+// renamed, the new version is written in its place while the old one keeps
+// running, and removeReplacedFiles deletes the old file on a later run.
+func moveAsideInUseFile(filePath string) error {
+	if runtime.GOOS != "windows" {
+		return nil
+	}
+
+	info, err := os.Lstat(filePath)
+	if err != nil || !info.Mode().IsRegular() {
+		return nil
+	}
+
+	if os.Remove(filePath) == nil {
+		return nil
+	}
+
+	// An older copy might still be running as well
+	for i := 0; i < maxReplacedFiles; i++ {
+		oldPath := filePath + replacedFileSuffix
+		if i > 0 {
+			oldPath = fmt.Sprintf("%s.%d%s", filePath, i, replacedFileSuffix)
+		}
+
+		err = os.Remove(oldPath)
+		if err == nil || os.IsNotExist(err) {
+			return os.Rename(filePath, oldPath)
+		}
+	}
+
+	//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+	return fmt.Errorf("Could not replace '%s' because it is in use, close all running copies and try again.", filePath)
+}
+
+// Removes the directory, files that are in use are moved aside on Windows
+// and left in it
+func removeDirectoryInUse(directory string) error {
+	err := os.RemoveAll(directory)
+	if err == nil || runtime.GOOS != "windows" {
+		return err
+	}
+
+	return filepath.WalkDir(directory, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || strings.HasSuffix(filePath, replacedFileSuffix) {
+			return err
+		}
+
+		return moveAsideInUseFile(filePath)
+	})
+}
+
+// Deletes the files that were moved aside by earlier installs, unless they
+// are still in use
+func removeReplacedFiles(directories ...string) {
+	if runtime.GOOS != "windows" {
+		return
+	}
+
+	for _, directory := range directories {
+		filepath.WalkDir(directory, func(filePath string, entry fs.DirEntry, err error) error {
+			if err == nil && !entry.IsDir() && strings.HasSuffix(filePath, replacedFileSuffix) {
+				os.Remove(filePath)
+			}
+			return nil
+		})
+	}
+}
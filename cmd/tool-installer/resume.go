@@ -0,0 +1,109 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// Returns the file in the cache directory where the asset is downloaded to,
+// so that an interrupted download can be continued by the next run. Each tool
+// has its own directory, which only keeps the file of the current download.
+func getPartialFilePath(name string, release *Release, asset *Asset) (string, error) {
+	cacheFilePath, err := getCacheFilePath()
+	if err != nil {
+		return "", err
+	}
+
+	key := sha256.Sum256([]byte(release.TagName + "\n" + asset.Name + "\n" + asset.BrowserDownloadUrl))
+	fileName := hex.EncodeToString(key[:8]) + ".part"
+	directory := filepath.Join(filepath.Dir(cacheFilePath), "partial", name)
+
+	entries, _ := os.ReadDir(directory)
+	for _, entry := range entries {
+		if entry.Name() != fileName {
+			os.Remove(filepath.Join(directory, entry.Name()))
+		}
+	}
+
+	return filepath.Join(directory, fileName), nil
+}
+
+// Whether the server only sent the rest of the content after the offset
+func isPartialResponse(body io.Reader) bool {
+	response, ok := body.(*ResponseBody)
+	return ok && response.partial
+}
+
+// Downloads the asset into the partial file, continuing after the part that
+// is already there if the server supports range requests, and feeds the whole
+// content into the hash. The caller removes the complete file when done.
+func (client *Downloader) downloadToPartialFile(source Source, asset *Asset, name string, filePath string, hash hash.Hash) (*os.File, *io.SectionReader, error) {
+	directory := filepath.Dir(filePath)
+	err := makeOutputDirectory(&directory)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file, err := os.OpenFile(filePath, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The part that is already there is hashed again, as the hash is not kept
+	hash.Reset()
+	offset, err := io.Copy(hash, file)
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	body, err := source.OpenAsset(asset, offset)
+	var statusError *StatusError
+	if offset > 0 && errors.As(err, &statusError) && statusError.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		// The asset changed or the file is already complete, start over
+		body, err = source.OpenAsset(asset, 0)
+	}
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	defer body.Close()
+
+	if offset > 0 && !isPartialResponse(body) {
+		offset = 0
+		hash.Reset()
+		err = file.Truncate(0)
+		if err == nil {
+			_, err = file.Seek(0, io.SeekStart)
+		}
+		if err != nil {
+			file.Close()
+			return nil, nil, err
+		}
+	}
+
+	total := getContentLength(body)
+	if total >= 0 {
+		total += offset
+	} else if asset.Size > 0 {
+		total = asset.Size
+	}
+
+	bar := client.progress.newBar(name, offset, total)
+	written, err := io.Copy(file, io.TeeReader(bar.wrap(client.throttleReader(body)), hash))
+	bar.finish()
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	return file, io.NewSectionReader(file, 0, offset+written), nil
+}
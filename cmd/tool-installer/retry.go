@@ -0,0 +1,138 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// How requests that failed for a transient reason are repeated. Unset
+// entries use the defaults below.
+type RetryConfig struct {
+	// How often a request is repeated
+	Count *int `json:"count,omitempty"`
+	// Wait before the first repetition, doubled for every further one
+	Delay string `json:"delay,omitempty"`
+	// Fraction of the wait that is randomly added or removed, from 0 to 1
+	Jitter *float64 `json:"jitter,omitempty"`
+}
+
+const (
+	defaultRetryCount  = 3
+	defaultRetryDelay  = time.Second
+	defaultRetryJitter = 0.2
+)
+
+type RetryPolicy struct {
+	count  int
+	delay  time.Duration
+	jitter float64
+}
+
+// Returned for responses without status 200 OK
+type StatusError struct {
+	StatusCode int
+}
+
+func (err *StatusError) Error() string {
+	return fmt.Sprintf(rateLimitText, err.StatusCode)
+}
+
+func (config *Configuration) getRetryPolicy() (RetryPolicy, error) {
+	result := RetryPolicy{count: defaultRetryCount, delay: defaultRetryDelay, jitter: defaultRetryJitter}
+	if config.Retry == nil {
+		return result, nil
+	}
+
+	if config.Retry.Count != nil {
+		if *config.Retry.Count < 0 {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return result, fmt.Errorf("Invalid retry count %d, expected 0 or more", *config.Retry.Count)
+		}
+		result.count = *config.Retry.Count
+	}
+
+	if config.Retry.Delay != "" {
+		delay, err := time.ParseDuration(config.Retry.Delay)
+		if err != nil || delay < 0 {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return result, fmt.Errorf("Invalid retry delay '%s', expected a duration like '500ms' or '2s'", config.Retry.Delay)
+		}
+		result.delay = delay
+	}
+
+	if config.Retry.Jitter != nil {
+		if *config.Retry.Jitter < 0 || *config.Retry.Jitter > 1 {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return result, fmt.Errorf("Invalid retry jitter %v, expected a number from 0 to 1", *config.Retry.Jitter)
+		}
+		result.jitter = *config.Retry.Jitter
+	}
+
+	return result, nil
+}
+
+// Whether the error is likely to go away when the request is repeated:
+// server errors, timeouts and connections that were reset or cut off
+func isTransientError(err error) bool {
+	var statusError *StatusError
+	if errors.As(err, &statusError) {
+		return statusError.StatusCode >= 500 || statusError.StatusCode == http.StatusTooManyRequests
+	}
+
+	var netError net.Error
+	if errors.As(err, &netError) && netError.Timeout() {
+		return true
+	}
+
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNABORTED) || errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// Returns the wait before the given repetition, starting at 0
+func (policy *RetryPolicy) getWait(attempt int) time.Duration {
+	wait := float64(policy.delay) * float64(int64(1)<<min(attempt, 16))
+	wait *= 1 + policy.jitter*(2*rand.Float64()-1)
+
+	return time.Duration(wait)
+}
+
+func describeError(err error) string {
+	var statusError *StatusError
+	if errors.As(err, &statusError) {
+		return fmt.Sprintf("status code %d", statusError.StatusCode)
+	}
+
+	return err.Error()
+}
+
+// Calls work until it succeeds, fails with an error that is not transient or
+// the retries are used up, and returns its last error
+func (client *Downloader) withRetries(description string, work func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := work()
+		if err == nil || attempt >= client.retry.count || !isTransientError(err) {
+			return err
+		}
+
+		if client.context.Err() != nil {
+			return err
+		}
+
+		wait := client.retry.getWait(attempt)
+		logWarning("%s failed with %s, retrying in %v (%d of %d).", description, describeError(err), wait.Round(100*time.Millisecond), attempt+1, client.retry.count)
+
+		select {
+		case <-time.After(wait):
+		case <-client.context.Done():
+			return err
+		}
+	}
+}
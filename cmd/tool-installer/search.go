@@ -0,0 +1,116 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type GithubSearchResponse struct {
+	TotalCount int                `json:"total_count"`
+	Items      []GithubRepository `json:"items"`
+}
+
+type GithubRepository struct {
+	FullName    string `json:"full_name"`
+	Description string `json:"description"`
+	Stars       int    `json:"stargazers_count"`
+	Archived    bool   `json:"archived"`
+}
+
+func isInteractive() bool {
+	stat, err := os.Stdin.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
+
+// Formats star counts like GitHub, e.g. '48.1k'
+func formatStars(stars int) string {
+	if stars < 1000 {
+		return strconv.Itoa(stars)
+	}
+
+	return fmt.Sprintf("%.1fk", float64(stars)/1000)
+}
+
+func printRepositories(repositories []GithubRepository) {
+	nameSize := 10
+	for _, repository := range repositories {
+		nameSize = max(nameSize, len(repository.FullName))
+	}
+
+	fmt.Printf("%3s  %-*s  %6s  %s\n\n", "#", nameSize, "Repository", "Stars", "Description")
+	for i, repository := range repositories {
+		description := repository.Description
+		if len(description) > maxShortListDescriptionLength {
+			description = description[:maxShortListDescriptionLength] + "..."
+		}
+		if repository.Archived {
+			description = "(archived) " + description
+		}
+		fmt.Printf("%3d  %-*s  %6s  %s\n", i+1, nameSize, repository.FullName, formatStars(repository.Stars), description)
+	}
+}
+
+// Searches GitHub for repositories and offers to add one of the results to
+// the configuration, see addTool
+func searchTools(configLocation *string, query string, limit int, downloadTimeout int) error {
+	config, err := getConfig(*configLocation)
+	if err != nil {
+		return err
+	}
+
+	downloader, err := newDownloader(downloadTimeout, &config)
+	if err != nil {
+		return err
+	}
+
+	token, err := downloader.getToken(&Tool{})
+	if err != nil {
+		return err
+	}
+
+	parameters := url.Values{}
+	parameters.Set("q", query)
+	parameters.Set("per_page", strconv.Itoa(limit))
+
+	var response GithubSearchResponse
+	err = downloader.downloadJson(githubApiUrl+"/search/repositories?"+parameters.Encode(), token, &response)
+	if err != nil {
+		return err
+	}
+
+	if len(response.Items) == 0 {
+		fmt.Printf("No repositories found for '%s'.\n", query)
+		return nil
+	}
+
+	printRepositories(response.Items)
+	if response.TotalCount > len(response.Items) {
+		fmt.Printf("\nShowing %d of %d results, refine the query or use '--limit' to see more.\n", len(response.Items), response.TotalCount)
+	}
+
+	if assumeYes || !isInteractive() {
+		return nil
+	}
+
+	fmt.Println()
+	answer, err := newPrompter().ask("Number of the repository to add, or empty to quit", "")
+	if err != nil || answer == "" {
+		return nil
+	}
+
+	number, err := strconv.Atoi(strings.TrimSpace(answer))
+	if err != nil || number < 1 || number > len(response.Items) {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("Invalid choice '%s', expected a number from 1 to %d.", answer, len(response.Items))
+	}
+
+	// No entries are given, so they are derived from the repository
+	flagSet := flag.NewFlagSet("add", flag.ContinueOnError)
+	return addTool(configLocation, response.Items[number-1].FullName, flagSet, addToolFlags(flagSet), downloadTimeout)
+}
@@ -0,0 +1,314 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type Semver struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+}
+
+// Finds the version in tags like '14.1.0', 'v0.10.2' or 'tool-1.4.0-rc.1'
+var semverRegex = regexp.MustCompile(`(\d+)(?:\.(\d+))?(?:\.(\d+))?(?:-([0-9A-Za-z.-]+))?`)
+
+func parseSemver(tag string) (Semver, bool) {
+	match := semverRegex.FindStringSubmatch(tag)
+	if match == nil {
+		return Semver{}, false
+	}
+
+	var result Semver
+	result.Major, _ = strconv.Atoi(match[1])
+	result.Minor, _ = strconv.Atoi(match[2])
+	result.Patch, _ = strconv.Atoi(match[3])
+	result.Prerelease = match[4]
+
+	return result, true
+}
+
+// Finds date-based tags like '2024-01-15', '2024.01.15' or '20240115'
+var dateTagRegex = regexp.MustCompile(`^(\d{4})[-._]?(\d{2})[-._]?(\d{2})(.*)$`)
+
+// Removes cosmetic differences from a tag, so that e.g. 'v1.4.0', 'tool-1.4'
+// and '1.4.0' or '2024-01-15' and '20240115' are considered the same version.
+// Rolling tags without any number like 'nightly' are only lowercased.
+func normalizeVersion(tag string) string {
+	result := strings.ToLower(strings.TrimSpace(tag))
+
+	start := strings.IndexAny(result, "0123456789")
+	if start < 0 {
+		return result
+	}
+	result = result[start:]
+
+	if match := dateTagRegex.FindStringSubmatch(result); match != nil {
+		return match[1] + match[2] + match[3] + match[4]
+	}
+
+	core, suffix := result, ""
+	if index := strings.IndexAny(result, "-+"); index >= 0 {
+		core, suffix = result[:index], result[index:]
+	}
+	for strings.HasSuffix(core, ".0") {
+		core = strings.TrimSuffix(core, ".0")
+	}
+
+	return core + suffix
+}
+
+func isSameVersion(a string, b string) bool {
+	return a == b || normalizeVersion(a) == normalizeVersion(b)
+}
+
+func compareInts(a int, b int) int {
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	}
+	return 0
+}
+
+// Compares the dot-separated prerelease identifiers as described by semver,
+// where a version without prerelease is higher than one with
+func comparePrerelease(a string, b string) int {
+	if a == b {
+		return 0
+	} else if a == "" {
+		return 1
+	} else if b == "" {
+		return -1
+	}
+
+	partsA := strings.Split(a, ".")
+	partsB := strings.Split(b, ".")
+
+	for i := 0; i < len(partsA) && i < len(partsB); i++ {
+		numberA, errA := strconv.Atoi(partsA[i])
+		numberB, errB := strconv.Atoi(partsB[i])
+
+		var result int
+		switch {
+		case errA == nil && errB == nil:
+			result = compareInts(numberA, numberB)
+		case errA == nil:
+			result = -1
+		case errB == nil:
+			result = 1
+		default:
+			result = strings.Compare(partsA[i], partsB[i])
+		}
+
+		if result != 0 {
+			return result
+		}
+	}
+
+	return compareInts(len(partsA), len(partsB))
+}
+
+func (v Semver) Compare(other Semver) int {
+	if result := compareInts(v.Major, other.Major); result != 0 {
+		return result
+	}
+	if result := compareInts(v.Minor, other.Minor); result != 0 {
+		return result
+	}
+	if result := compareInts(v.Patch, other.Patch); result != 0 {
+		return result
+	}
+
+	return comparePrerelease(v.Prerelease, other.Prerelease)
+}
+
+type comparison struct {
+	operator string
+	version  Semver
+}
+
+func (c comparison) matches(v Semver) bool {
+	result := v.Compare(c.version)
+
+	switch c.operator {
+	case "=":
+		return result == 0
+	case "!=":
+		return result != 0
+	case ">":
+		return result > 0
+	case ">=":
+		return result >= 0
+	case "<":
+		return result < 0
+	case "<=":
+		return result <= 0
+	}
+
+	return false
+}
+
+// A constraint is a list of alternatives ('||'), each of which is a list of
+// comparisons that all have to match
+type VersionConstraint [][]comparison
+
+var constraintRegex = regexp.MustCompile(`^(>=|<=|!=|>|<|=|~|\^)?\s*v?(\d+|[xX*])(?:\.(\d+|[xX*]))?(?:\.(\d+|[xX*]))?(?:-([0-9A-Za-z.-]+))?$`)
+
+func isWildcard(part string) bool {
+	return part == "" || part == "x" || part == "X" || part == "*"
+}
+
+// Expands a single term like '>=1.2', '~1.4' or '1.x' into plain comparisons
+func parseConstraintTerm(term string) ([]comparison, error) {
+	match := constraintRegex.FindStringSubmatch(term)
+	if match == nil {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return nil, fmt.Errorf("Invalid version constraint '%s'.", term)
+	}
+
+	operator := match[1]
+	major, minor, patch := match[2], match[3], match[4]
+
+	var version Semver
+	version.Major, _ = strconv.Atoi(major)
+	version.Minor, _ = strconv.Atoi(minor)
+	version.Patch, _ = strconv.Atoi(patch)
+	version.Prerelease = match[5]
+
+	if isWildcard(major) {
+		return []comparison{}, nil
+	}
+
+	// Partial versions and wildcards without operator select a range, e.g. 1.4 = 1.4.x
+	if operator == "" || operator == "=" {
+		if isWildcard(minor) {
+			operator = "^"
+		} else if isWildcard(patch) {
+			operator = "~"
+		} else {
+			return []comparison{{"=", version}}, nil
+		}
+	}
+
+	switch operator {
+	case "~":
+		upper := Semver{Major: version.Major + 1}
+		if !isWildcard(minor) {
+			upper = Semver{Major: version.Major, Minor: version.Minor + 1}
+		}
+		return []comparison{{">=", version}, {"<", upper}}, nil
+	case "^":
+		var upper Semver
+		switch {
+		case version.Major > 0 || isWildcard(minor):
+			upper = Semver{Major: version.Major + 1}
+		case version.Minor > 0 || isWildcard(patch):
+			upper = Semver{Minor: version.Minor + 1}
+		default:
+			upper = Semver{Patch: version.Patch + 1}
+		}
+		return []comparison{{">=", version}, {"<", upper}}, nil
+	case "<=", ">":
+		// '<=1.4' includes every 1.4.x, '>1.4' excludes them
+		if isWildcard(minor) {
+			version = Semver{Major: version.Major + 1}
+		} else if isWildcard(patch) {
+			version = Semver{Major: version.Major, Minor: version.Minor + 1}
+		} else {
+			return []comparison{{operator, version}}, nil
+		}
+		if operator == "<=" {
+			return []comparison{{"<", version}}, nil
+		}
+		return []comparison{{">=", version}}, nil
+	}
+
+	return []comparison{{operator, version}}, nil
+}
+
+// Parses constraints like '>=0.10, <0.12', '~1.4' or '^2 || ^3'
+func parseVersionConstraint(constraint string) (VersionConstraint, error) {
+	var result VersionConstraint
+
+	for _, alternative := range strings.Split(constraint, "||") {
+		var comparisons []comparison
+
+		// Allow both '>=1.0, <2.0' and '>=1.0 <2.0'
+		normalized := strings.ReplaceAll(alternative, ",", " ")
+		for _, operator := range []string{">=", "<=", "!=", ">", "<", "=", "~", "^"} {
+			normalized = strings.ReplaceAll(normalized, operator+" ", operator)
+		}
+
+		for _, term := range strings.Fields(normalized) {
+			parsed, err := parseConstraintTerm(term)
+			if err != nil {
+				return nil, err
+			}
+			comparisons = append(comparisons, parsed...)
+		}
+
+		result = append(result, comparisons)
+	}
+
+	return result, nil
+}
+
+func (constraint VersionConstraint) matches(v Semver) bool {
+	for _, alternative := range constraint {
+		matches := true
+		for _, c := range alternative {
+			if !c.matches(v) {
+				matches = false
+				break
+			}
+		}
+
+		if matches {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns the highest release whose tag satisfies the constraint, ignoring
+// prereleases unless allowPrerelease is set
+func selectRelease(releases []Release, constraint string, allowPrerelease bool) (Release, error) {
+	parsed, err := parseVersionConstraint(constraint)
+	if err != nil {
+		return Release{}, err
+	}
+
+	var best Release
+	var bestVersion Semver
+	found := false
+
+	for _, release := range releases {
+		if release.Draft || (release.Prerelease && !allowPrerelease) {
+			continue
+		}
+
+		version, ok := parseSemver(release.TagName)
+		if !ok || (version.Prerelease != "" && !allowPrerelease) || !parsed.matches(version) {
+			continue
+		}
+
+		if !found || version.Compare(bestVersion) > 0 {
+			best, bestVersion, found = release, version, true
+		}
+	}
+
+	if !found {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return best, fmt.Errorf("No release satisfies the version constraint '%s'.", constraint)
+	}
+
+	return best, nil
+}
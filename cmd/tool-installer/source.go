@@ -0,0 +1,181 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"time"
+)
+
+// A Source is a place tools can be downloaded from, e.g. a forge's release API.
+// Adding support for a new forge only requires a new implementation and an
+// entry in getSource, the install and check logic works on any Source.
+type Source interface {
+	// Returns up to limit of the most recent releases, newest first
+	ListReleases(limit int) ([]Release, error)
+	// Returns the latest stable release
+	GetLatest() (Release, error)
+	// Returns the release with the given tag
+	GetByTag(tag string) (Release, error)
+	// Returns the content of the asset for streaming, which the caller closes.
+	// With an offset, the rest of the asset after it may be returned instead.
+	OpenAsset(asset *Asset, offset int64) (io.ReadCloser, error)
+	// Writes the source code of the release as a .tar.gz archive into the file
+	DownloadSourceArchive(release *Release, file *os.File) error
+}
+
+// Downloads small assets like checksum files and signatures into memory
+func readAsset(source Source, asset *Asset) ([]byte, error) {
+	body, err := source.OpenAsset(asset, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	content, err := io.ReadAll(body)
+	if caching, ok := source.(*CachingSource); ok && err == nil {
+		caching.storeAsset(asset, content)
+	}
+
+	return content, err
+}
+
+// Returns the release to install for the tool, which is the pinned version if
+// there is one, the highest release satisfying the version constraint if
+// there is one, and the latest release otherwise. Prereleases are only
+// considered if the tool allows them.
+func getToolRelease(source Source, tool *Tool) (Release, error) {
+	if tool.Version != "" {
+		return source.GetByTag(tool.Version)
+	}
+
+	if tool.VersionConstraint != "" || tool.allowsPrerelease() || len(tool.IgnoreVersions) > 0 {
+		releases, err := source.ListReleases(maxListedReleases)
+		if err != nil {
+			return Release{}, err
+		}
+
+		releases = filterIgnoredReleases(releases, tool.IgnoreVersions)
+
+		if tool.VersionConstraint != "" {
+			return selectRelease(releases, tool.VersionConstraint, tool.allowsPrerelease())
+		}
+
+		return getNewestRelease(releases, tool.allowsPrerelease())
+	}
+
+	return source.GetLatest()
+}
+
+// Returns the newest release, relying on the source listing the releases
+// newest first
+func getNewestRelease(releases []Release, allowPrerelease bool) (Release, error) {
+	for _, release := range releases {
+		if !release.Draft && (allowPrerelease || !release.Prerelease) {
+			return release, nil
+		}
+	}
+
+	//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+	return Release{}, errors.New("The repository has no matching releases.")
+}
+
+// Removes the releases whose tag is listed in ignoreVersions, either exactly
+// or as a regular expression matching the whole tag. Entries that are not
+// valid regular expressions only match exactly.
+func filterIgnoredReleases(releases []Release, ignoreVersions []string) []Release {
+	if len(ignoreVersions) == 0 {
+		return releases
+	}
+
+	regexes := make([]*regexp.Regexp, len(ignoreVersions))
+	for i, pattern := range ignoreVersions {
+		regexes[i], _ = regexp.Compile("^(?:" + pattern + ")$")
+	}
+
+	result := make([]Release, 0, len(releases))
+	for _, release := range releases {
+		ignored := false
+		for i, regex := range regexes {
+			if release.TagName == ignoreVersions[i] || (regex != nil && regex.MatchString(release.TagName)) {
+				ignored = true
+				break
+			}
+		}
+
+		if !ignored {
+			result = append(result, release)
+		}
+	}
+
+	return result
+}
+
+// How many releases are considered when selecting a version from a list
+const maxListedReleases = 300
+
+// Limits the releases to the first limit entries
+func limitReleases(releases []Release, limit int) []Release {
+	if len(releases) > limit {
+		return releases[:limit]
+	}
+
+	return releases
+}
+
+func errNoSourceArchive(kind string) error {
+	//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+	return fmt.Errorf("Building from source is not supported for %s.", kind)
+}
+
+// Returns the source of the tool, which only reads the cache in offline mode
+func (client *Downloader) getSource(tool *Tool) (Source, error) {
+	key := getSourceKey(tool)
+	if offlineMode {
+		return &OfflineSource{key: key, downloads: client.downloadCache}, nil
+	}
+
+	source, err := client.getOnlineSource(tool)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CachingSource{Source: source, key: key, downloads: client.downloadCache}, nil
+}
+
+func (client *Downloader) getOnlineSource(tool *Tool) (Source, error) {
+	token, err := client.getToken(tool)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tool.Headers) > 0 {
+		client = client.withHeaders(getToolHeadersHost(tool), tool.Headers)
+	}
+
+	if tool.Timeout > 0 {
+		client = client.withTimeout(time.Duration(tool.Timeout) * time.Second)
+	}
+
+	if tool.UrlTemplate != "" {
+		return &UrlSource{client: client, tool: tool, token: token}, nil
+	}
+
+	if tool.OciImage != "" {
+		return newOciSource(client, tool, token)
+	}
+
+	if tool.Gist != "" {
+		return &GistSource{client: client, id: tool.Gist, revision: tool.GistRevision, token: token}, nil
+	}
+
+	if isGithubHost(tool.Host) {
+		return &GithubSource{client: client, owner: tool.Owner, repository: tool.Repository, token: token}, nil
+	}
+
+	return &GiteaSource{client: client, host: tool.Host, owner: tool.Owner, repository: tool.Repository, token: token}, nil
+}
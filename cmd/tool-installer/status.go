@@ -0,0 +1,158 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type StatusEntry struct {
+	Name    string
+	Version string
+	Status  string
+	// Whether the configuration, cache and disk disagree about the tool
+	problem bool
+}
+
+func (entry StatusEntry) GetName() string {
+	return entry.Name
+}
+
+// Returns the state of a tool from its configuration, cache entry and files
+func getToolStatus(name string, config *Configuration, cache *Cache) StatusEntry {
+	entry := StatusEntry{Name: name, Version: cache.Tools[name], Status: "installed"}
+
+	tool, configured := config.Tools[name]
+	_, installed := cache.Tools[name]
+
+	switch {
+	case !configured:
+		entry.Status = "not in configuration"
+		entry.problem = true
+	case !installed:
+		entry.Status = "not installed"
+	case len(cache.Installed[name].Files) == 0:
+		entry.Status = "installed, no files recorded"
+	default:
+		if missing := getMissingFiles(name, cache, config); len(missing) > 0 {
+			entry.Status = "missing " + strings.Join(missing, ", ")
+			entry.problem = true
+		}
+	}
+
+	if configured && !tool.isEnabled() {
+		entry.Status += " (disabled)"
+	}
+	if cache.Held[name] {
+		entry.Status += " (held)"
+	}
+
+	return entry
+}
+
+// Adds the top-level names of the installed files in the installation
+// directory to known
+func addInstalledFileNames(known map[string]bool, files []string) {
+	for _, file := range files {
+		if !filepath.IsAbs(file) {
+			known[strings.SplitN(filepath.ToSlash(file), "/", 2)[0]] = true
+		}
+	}
+}
+
+// Returns the files in the directory that are not known, except hidden files
+// and the ones moved aside while in use
+func getUnknownFiles(directory string, known map[string]bool) []string {
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		return nil
+	}
+
+	var result []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !known[name] && !strings.HasPrefix(name, ".") && !strings.HasSuffix(name, replacedFileSuffix) {
+			result = append(result, name)
+		}
+	}
+
+	return result
+}
+
+// Prints the state of every tool in the configuration or the cache and the
+// unknown files in the installation directory. With a profile, the installed
+// tools of other profiles are left out. Returns false if tools are
+// missing files or installed without a configuration entry.
+func printStatus(configLocation *string) bool {
+	config, err := getConfig(*configLocation)
+	if err != nil {
+		printConfigError(err)
+		os.Exit(1)
+	}
+
+	cache, err := getCache()
+	if err != nil {
+		logError("Failed to obtain cache. Message: %v", err)
+		os.Exit(1)
+	}
+
+	// Tools of other profiles are installed, but not part of this one
+	allTools, err := loadConfiguration(*configLocation)
+	if err != nil {
+		printConfigError(err)
+		os.Exit(1)
+	}
+
+	names := config.getToolNames()
+	for name := range cache.Tools {
+		if _, found := allTools.Tools[name]; !found {
+			names = append(names, name)
+		}
+	}
+
+	nameSize := 4
+	versionSize := 7
+	entries := make([]StatusEntry, 0, len(names))
+	for _, name := range names {
+		entry := getToolStatus(name, &config, &cache)
+		nameSize = max(nameSize, len(entry.Name))
+		versionSize = max(versionSize, len(entry.Version))
+		entries = append(entries, entry)
+	}
+
+	sort.Sort(ByName[StatusEntry]{entries})
+
+	ok := true
+	missing := false
+	fmt.Printf("%-*s    %-*s    %s\n\n", nameSize, "Name", versionSize, "Version", "Status")
+	for _, entry := range entries {
+		fmt.Printf("%-*s    %-*s    %s\n", nameSize, entry.Name, versionSize, entry.Version, entry.Status)
+		if entry.problem {
+			ok = false
+			missing = missing || strings.HasPrefix(entry.Status, "missing ")
+		}
+	}
+
+	known := make(map[string]bool)
+	for _, installed := range cache.Installed {
+		addInstalledFileNames(known, installed.Files)
+	}
+
+	unknown := getUnknownFiles(config.InstallationDirectory, known)
+	if len(unknown) > 0 {
+		fmt.Printf("\nFiles in '%s' that were not installed by tooli:\n", config.InstallationDirectory)
+		for _, file := range unknown {
+			fmt.Printf("    %s\n", file)
+		}
+	}
+
+	if missing {
+		fmt.Println("\nReinstall tools with missing files with 'tooli install --force NAME'.")
+	}
+
+	return ok
+}
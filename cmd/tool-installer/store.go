@@ -0,0 +1,270 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A version of a tool kept in the store, next to the copies of its files.
+// Versions that were rolled back from are superseded until the next install.
+type StoredVersion struct {
+	Version     string        `json:"version"`
+	InstalledAt time.Time     `json:"installed_at"`
+	Installed   InstalledTool `json:"installed"`
+	Superseded  bool          `json:"superseded,omitempty"`
+
+	directory string
+}
+
+const storeInfoFile = "info.json"
+
+func getStoreDirectory(name string) (string, error) {
+	cacheFilePath, err := getCacheFilePath()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(filepath.Dir(cacheFilePath), "store", name), nil
+}
+
+// Absolute paths of auxiliary files are kept below the version's directory too
+func getStoredFilePath(versionDirectory string, file string) string {
+	return filepath.Join(versionDirectory, "files", strings.TrimPrefix(file, filepath.VolumeName(file)))
+}
+
+// Returns the permissions of the file, which are kept when storing and restoring
+// installed files, falls back to an executable file if they cannot be read
+func getFileMode(filePath string) os.FileMode {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return 0755
+	}
+
+	return info.Mode().Perm()
+}
+
+// Copies the file, symlinks are copied as symlinks
+func copyFile(source string, destination string, mode os.FileMode) error {
+	if info, err := os.Lstat(source); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(source)
+		if err != nil {
+			return err
+		}
+
+		err = os.MkdirAll(filepath.Dir(destination), 0755)
+		if err != nil {
+			return err
+		}
+
+		return linkFile(target, destination)
+	}
+
+	file, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return writeFile(destination, file, mode)
+}
+
+// Copies the installed files of a tool into the store and removes the oldest
+// stored versions beyond the configured limit
+func saveToStore(name string, version string, installed InstalledTool, config *Configuration) error {
+	keep := config.getKeepVersions()
+	if keep <= 0 || len(installed.Files) == 0 {
+		return nil
+	}
+
+	storeDirectory, err := getStoreDirectory(name)
+	if err != nil {
+		return err
+	}
+
+	versionDirectory := filepath.Join(storeDirectory, sanitizeVersion(version))
+
+	err = os.RemoveAll(versionDirectory)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range installed.Files {
+		source := getInstalledFilePath(config.InstallationDirectory, file)
+		err = copyFile(source, getStoredFilePath(versionDirectory, file), getFileMode(source))
+		if err != nil {
+			return err
+		}
+	}
+
+	info := StoredVersion{Version: version, InstalledAt: time.Now(), Installed: installed, directory: versionDirectory}
+	err = info.write()
+	if err != nil {
+		return err
+	}
+
+	versions, err := getStoredVersions(name)
+	if err != nil {
+		return err
+	}
+
+	for i := range versions {
+		if i >= keep {
+			os.RemoveAll(versions[i].directory)
+		} else if versions[i].Superseded {
+			// A new install starts a new history, rollbacks may return to all versions
+			versions[i].Superseded = false
+			versions[i].write()
+		}
+	}
+
+	return nil
+}
+
+func (info *StoredVersion) write() error {
+	bytes, err := json.MarshalIndent(info, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(info.directory, storeInfoFile), bytes, 0644)
+}
+
+// Tags can contain characters that are not allowed in file names
+func sanitizeVersion(version string) string {
+	result := []rune(version)
+	for i, r := range result {
+		if r == '/' || r == '\\' || r == ':' {
+			result[i] = '_'
+		}
+	}
+
+	return string(result)
+}
+
+// Returns the stored versions of a tool, most recently installed first
+func getStoredVersions(name string) ([]StoredVersion, error) {
+	storeDirectory, err := getStoreDirectory(name)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(storeDirectory)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var result []StoredVersion
+	for _, entry := range entries {
+		directory := filepath.Join(storeDirectory, entry.Name())
+
+		bytes, err := os.ReadFile(filepath.Join(directory, storeInfoFile))
+		if err != nil {
+			continue
+		}
+
+		var info StoredVersion
+		if json.Unmarshal(bytes, &info) != nil {
+			continue
+		}
+		info.directory = directory
+
+		result = append(result, info)
+	}
+
+	sort.Slice(result, func(i int, j int) bool {
+		return result[i].InstalledAt.After(result[j].InstalledAt)
+	})
+
+	return result, nil
+}
+
+// Reinstalls the most recently installed version of the tool that differs
+// from the current one and was not rolled back from, so that repeated
+// rollbacks go further back
+func rollbackTool(configLocation *string, name string) error {
+	config, err := getConfig(*configLocation)
+	if err != nil {
+		return err
+	}
+
+	cache, err := getCache()
+	if err != nil {
+		return err
+	}
+
+	versions, err := getStoredVersions(name)
+	if err != nil {
+		return err
+	}
+
+	current := cache.Tools[name]
+
+	var previous *StoredVersion
+	var rolledBack *StoredVersion
+	for i := range versions {
+		if versions[i].Version == current {
+			if rolledBack == nil {
+				rolledBack = &versions[i]
+			}
+		} else if previous == nil && !versions[i].Superseded {
+			previous = &versions[i]
+		}
+	}
+
+	if previous == nil {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return errors.New("No previous version is available for a rollback.")
+	}
+
+	// Files that only the current version has, e.g. a renamed binary, are removed
+	previousFiles := make(map[string]bool)
+	for _, file := range previous.Installed.Files {
+		previousFiles[file] = true
+	}
+
+	for _, file := range cache.Installed[name].Files {
+		if previousFiles[file] {
+			continue
+		}
+
+		err = os.Remove(getInstalledFilePath(config.InstallationDirectory, file))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	for _, file := range previous.Installed.Files {
+		installedPath := getInstalledFilePath(config.InstallationDirectory, file)
+		target, err := prepareOutputFile(filepath.Dir(installedPath), filepath.Base(installedPath))
+		if err != nil {
+			return err
+		}
+
+		source := getStoredFilePath(previous.directory, file)
+		err = copyFile(source, target, getFileMode(source))
+		if err != nil {
+			return err
+		}
+	}
+
+	if rolledBack != nil {
+		rolledBack.Superseded = true
+		rolledBack.write()
+	}
+
+	cache.Tools[name] = previous.Version
+	cache.Installed[name] = previous.Installed
+
+	logInfo("Rolled back '%s' from %s to %s.", name, current, previous.Version)
+
+	return cache.writeCache()
+}
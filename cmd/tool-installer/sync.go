@@ -0,0 +1,120 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Returns the installed tools that are no longer in the configuration. The
+// tools of all profiles count as configured.
+func getUnconfiguredTools(configLocation string, cache *Cache) ([]string, error) {
+	allTools, err := loadConfiguration(configLocation)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for name := range cache.Tools {
+		if _, found := allTools.Tools[name]; !found {
+			result = append(result, name)
+		}
+	}
+	sort.Strings(result)
+
+	return result, nil
+}
+
+// Prints what 'sync' would do without changing anything
+func printSyncPlan(downloader *Downloader, config *Configuration, cache *Cache, removals []string) {
+	changes := 0
+	for _, entry := range getUiEntries(downloader, config, cache) {
+		switch {
+		case entry.Error != "":
+			fmt.Printf("Unknown   %s: %s\n", entry.Name, entry.Error)
+		case entry.getDefaultAction() == uiActionInstall:
+			fmt.Printf("Install   %s %s\n", entry.Name, entry.Available)
+			changes++
+		case entry.getDefaultAction() == uiActionUpdate && entry.Held:
+			fmt.Printf("Held      %s %s (%s is available)\n", entry.Name, entry.Installed, entry.Available)
+		case entry.getDefaultAction() == uiActionUpdate:
+			fmt.Printf("Update    %s %s -> %s\n", entry.Name, entry.Installed, entry.Available)
+			changes++
+		}
+	}
+
+	for _, name := range removals {
+		fmt.Printf("Remove    %s %s\n", name, cache.Tools[name])
+		changes++
+	}
+
+	if changes == 0 {
+		fmt.Println("Everything is in sync.")
+	} else {
+		fmt.Printf("\n%d change(s), run 'tooli sync' without '--dry-run' to apply them.\n", changes)
+	}
+}
+
+// Makes the installed tools match the configuration: installs missing tools,
+// updates outdated ones and removes the files of tools that are no longer
+// configured. With dryRun, only prints what would be done.
+func syncTools(configLocation *string, downloadTimeout int, jobs int, dryRun bool) {
+	config, err := getConfig(*configLocation)
+	if err != nil {
+		printConfigError(err)
+		os.Exit(1)
+	}
+
+	cache, err := getCache()
+	if err != nil {
+		logError("Failed to obtain cache. Message: %v", err)
+		os.Exit(1)
+	}
+
+	removals, err := getUnconfiguredTools(*configLocation, &cache)
+	if err != nil {
+		printConfigError(err)
+		os.Exit(1)
+	}
+
+	if dryRun {
+		downloader, err := newDownloader(downloadTimeout, &config)
+		if err != nil {
+			logError("%v", err)
+			os.Exit(1)
+		}
+
+		printSyncPlan(&downloader, &config, &cache, removals)
+		return
+	}
+
+	var results []ToolResult
+	for _, name := range removals {
+		version := cache.Tools[name]
+		err = uninstallTool(name, &config, &cache)
+		if errors.Is(err, errNoInstalledFiles) {
+			logWarning("Kept tool '%s': %v", name, err)
+			results = append(results, ToolResult{Tool: name, Action: actionSkipped, OldVersion: version, Error: err.Error()})
+			continue
+		}
+		if err != nil {
+			logError("Could not remove tool '%s': %v", name, err)
+			os.Exit(1)
+		}
+		logInfo("Removed tool '%s'.", name)
+		results = append(results, ToolResult{Tool: name, Action: actionRemoved, OldVersion: version})
+	}
+
+	if len(removals) > 0 {
+		err = cache.writeCache()
+		if err != nil {
+			logError("%v", err)
+			os.Exit(1)
+		}
+	}
+
+	installTools(configLocation, InstallToolsOptions{DownloadTimeout: downloadTimeout, Jobs: jobs, Results: results})
+}
@@ -0,0 +1,16 @@
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !linux && !windows
+
+package main
+
+import "errors"
+
+func enableRawInput() (func(), error) {
+	//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+	return nil, errors.New("The terminal UI is not supported on this platform.")
+}
+
+func getTerminalHeight() int {
+	return 24
+}
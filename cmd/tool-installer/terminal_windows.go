@@ -0,0 +1,49 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// Switches the console to reading single key presses without echo and to
+// escape sequences for keys and output, returns a function that restores the
+// previous modes
+func enableRawInput() (func(), error) {
+	input := windows.Handle(os.Stdin.Fd())
+	var inputMode uint32
+	err := windows.GetConsoleMode(input, &inputMode)
+	if err != nil {
+		return nil, err
+	}
+
+	rawMode := inputMode&^(windows.ENABLE_ECHO_INPUT|windows.ENABLE_LINE_INPUT|windows.ENABLE_PROCESSED_INPUT) | windows.ENABLE_VIRTUAL_TERMINAL_INPUT
+	err = windows.SetConsoleMode(input, rawMode)
+	if err != nil {
+		return nil, err
+	}
+
+	output := windows.Handle(os.Stdout.Fd())
+	var outputMode uint32
+	if windows.GetConsoleMode(output, &outputMode) == nil {
+		windows.SetConsoleMode(output, outputMode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING)
+	}
+
+	return func() {
+		windows.SetConsoleMode(input, inputMode)
+		windows.SetConsoleMode(output, outputMode)
+	}, nil
+}
+
+// Returns the number of rows of the console window, 24 if it is unknown
+func getTerminalHeight() int {
+	var info windows.ConsoleScreenBufferInfo
+	err := windows.GetConsoleScreenBufferInfo(windows.Handle(os.Stdout.Fd()), &info)
+	if err != nil {
+		return 24
+	}
+
+	return int(info.Window.Bottom-info.Window.Top) + 1
+}
@@ -0,0 +1,164 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Limits the bandwidth of all asset downloads together, set by '--limit-rate'
+type Throttle struct {
+	mutex sync.Mutex
+	// Bytes per second
+	rate int64
+	// When the bytes read so far are allowed by the rate
+	next time.Time
+}
+
+type throttledReader struct {
+	reader   io.Reader
+	throttle *Throttle
+	context  context.Context
+}
+
+// The body of a throttled asset download. Its request is cancelled if a single
+// read takes longer than the timeout, the time between reads, e.g. waiting for
+// the throttle, does not count.
+type idleTimeoutBody struct {
+	io.ReadCloser
+	cancel  context.CancelFunc
+	timeout time.Duration
+	stalled atomic.Bool
+}
+
+func newThrottle(rate int64) *Throttle {
+	if rate <= 0 {
+		return nil
+	}
+
+	return &Throttle{rate: rate}
+}
+
+// Limits asset downloads to the given bytes per second
+func (client *Downloader) limitBandwidth(rate int64) {
+	client.throttle = newThrottle(rate)
+}
+
+func errStalled(timeout time.Duration) error {
+	//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+	return fmt.Errorf("No data was received for %v", timeout)
+}
+
+// Sends the request of an asset download. A throttled download of a large
+// asset takes longer than the request timeout, which therefore only applies
+// to waiting for the response and to every single read of the body.
+func (client *Downloader) doAsset(req *http.Request) (*http.Response, error) {
+	timeout := client.client.Timeout
+	if client.throttle == nil || timeout <= 0 {
+		return client.do(req)
+	}
+
+	ctx, cancel := context.WithCancel(req.Context())
+	timer := time.AfterFunc(timeout, cancel)
+
+	untimed := *client
+	untimed.client.Timeout = 0
+	resp, err := untimed.do(req.WithContext(ctx))
+	if !timer.Stop() {
+		if err == nil {
+			resp.Body.Close()
+		}
+		cancel()
+		return nil, errStalled(timeout)
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	resp.Body = &idleTimeoutBody{ReadCloser: resp.Body, cancel: cancel, timeout: timeout}
+	return resp, nil
+}
+
+func (body *idleTimeoutBody) Read(buffer []byte) (int, error) {
+	timer := time.AfterFunc(body.timeout, func() {
+		body.stalled.Store(true)
+		body.cancel()
+	})
+	n, err := body.ReadCloser.Read(buffer)
+	timer.Stop()
+
+	if err != nil && body.stalled.Load() {
+		return n, errStalled(body.timeout)
+	}
+
+	return n, err
+}
+
+// Also releases the context of the request
+func (body *idleTimeoutBody) Close() error {
+	err := body.ReadCloser.Close()
+	body.cancel()
+
+	return err
+}
+
+// Returns a reader that reads at most at the throttle's rate, shared with all
+// other readers of the throttle. Without a throttle, the reader is returned.
+func (client *Downloader) throttleReader(reader io.Reader) io.Reader {
+	if client.throttle == nil {
+		return reader
+	}
+
+	return &throttledReader{reader: reader, throttle: client.throttle, context: client.context}
+}
+
+func (reader *throttledReader) Read(buffer []byte) (int, error) {
+	// Small reads keep the rate even instead of bursting once per second
+	chunk := reader.throttle.rate / 10
+	if chunk < 1024 {
+		chunk = 1024
+	}
+	if int64(len(buffer)) > chunk {
+		buffer = buffer[:chunk]
+	}
+
+	n, err := reader.reader.Read(buffer)
+	if n > 0 {
+		waitErr := reader.throttle.wait(reader.context, int64(n))
+		if err == nil {
+			err = waitErr
+		}
+	}
+
+	return n, err
+}
+
+// Waits until the given number of bytes fit into the rate
+func (throttle *Throttle) wait(ctx context.Context, bytes int64) error {
+	throttle.mutex.Lock()
+	now := time.Now()
+	if throttle.next.Before(now) {
+		throttle.next = now
+	}
+	throttle.next = throttle.next.Add(time.Duration(bytes) * time.Second / time.Duration(throttle.rate))
+	wait := time.Until(throttle.next)
+	throttle.mutex.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+
+	select {
+	case <-time.After(wait):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
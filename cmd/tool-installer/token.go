@@ -0,0 +1,202 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Describes where to obtain an access token from. Exactly one of the entries
+// should be set.
+type TokenSource struct {
+	Env     string `json:"env,omitempty"`
+	File    string `json:"file,omitempty"`
+	Command string `json:"command,omitempty"`
+}
+
+func runTokenCommand(command string) (string, error) {
+	cmd := newShellCommand(command)
+	cmd.Stderr = os.Stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+
+	return string(output), nil
+}
+
+func (source *TokenSource) resolve() (string, error) {
+	var token string
+
+	switch {
+	case source.Env != "":
+		token = os.Getenv(source.Env)
+		if token == "" {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return "", fmt.Errorf("The environment variable '%s' for the token is not set.", source.Env)
+		}
+	case source.File != "":
+		bytes, err := os.ReadFile(expandPath(source.File))
+		if err != nil {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return "", fmt.Errorf("Could not read token file: %v", err)
+		}
+		token = string(bytes)
+	case source.Command != "":
+		output, err := runTokenCommand(source.Command)
+		if err != nil {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return "", fmt.Errorf("Could not run token command '%s': %v", source.Command, err)
+		}
+		token = output
+	default:
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return "", errors.New("A token needs one of 'env', 'file' or 'command'.")
+	}
+
+	return strings.TrimSpace(token), nil
+}
+
+// Returns the host of the URL, or nothing if it cannot be parsed
+func getUrlHost(link string) string {
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+
+	return parsed.Host
+}
+
+func getToolHost(tool *Tool) string {
+	if tool.UrlTemplate != "" {
+		return getUrlHost(tool.UrlTemplate)
+	}
+
+	if tool.OciImage != "" {
+		registry, _, _ := strings.Cut(tool.OciImage, "/")
+		return registry
+	}
+
+	if isGithubHost(tool.Host) {
+		return githubHost
+	}
+
+	return tool.Host
+}
+
+// Returns the token for a tool, in order of priority from the tool's own token
+// configuration, the configuration of its host and, for GitHub, the
+// GITHUB_TOKEN environment variable
+func (client *Downloader) getToken(tool *Tool) (string, error) {
+	if tool.Token != nil {
+		return tool.Token.resolve()
+	}
+
+	return client.getHostToken(getToolHost(tool))
+}
+
+// Returns the token configured for the host, or, for GitHub, the GITHUB_TOKEN
+// environment variable
+func (client *Downloader) getHostToken(host string) (string, error) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	if token, found := client.tokenCache[host]; found {
+		return token, nil
+	}
+
+	if source, found := client.hostTokens[host]; found {
+		token, err := source.resolve()
+		if err != nil {
+			return "", err
+		}
+
+		client.tokenCache[host] = token
+		return token, nil
+	}
+
+	if host == githubHost && client.githubToken != "" {
+		return client.githubToken, nil
+	}
+
+	if client.credentialFallback {
+		token := getStoredToken(host)
+		client.tokenCache[host] = token
+		return token, nil
+	}
+
+	return "", nil
+}
+
+// Reads the token for the given host from the gh CLI's hosts.yml. Only the
+// small subset of YAML gh writes is understood, i.e. a top-level key per host
+// with an indented 'oauth_token' entry.
+func getGhToken(host string) string {
+	configDir, err := getGhConfigDirectory()
+	if err != nil {
+		return ""
+	}
+
+	bytes, err := os.ReadFile(filepath.Join(configDir, "hosts.yml"))
+	if err != nil {
+		return ""
+	}
+
+	inHost := false
+	for _, line := range strings.Split(string(bytes), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" {
+			continue
+		}
+
+		if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+			inHost = strings.TrimSuffix(strings.TrimSpace(line), ":") == host
+			continue
+		}
+
+		if inHost {
+			key, value, found := strings.Cut(strings.TrimSpace(line), ":")
+			if found && key == "oauth_token" {
+				return strings.Trim(strings.TrimSpace(value), `"'`)
+			}
+		}
+	}
+
+	return ""
+}
+
+// Asks git's credential helpers for the password stored for the given host,
+// without ever prompting the user
+func getGitCredentialToken(host string) string {
+	cmd := exec.Command("git", "credential", "fill")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("protocol=https\nhost=%s\n\n", host))
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_ASKPASS=", "SSH_ASKPASS=")
+
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+
+	for _, line := range strings.Split(string(output), "\n") {
+		if value, found := strings.CutPrefix(line, "password="); found {
+			return strings.TrimSpace(value)
+		}
+	}
+
+	return ""
+}
+
+func getStoredToken(host string) string {
+	if token := getGhToken(host); token != "" {
+		return token
+	}
+
+	return getGitCredentialToken(host)
+}
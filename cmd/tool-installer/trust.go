@@ -0,0 +1,116 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Trust is stored in the cache rather than the configuration, so that edits
+// to a shared configuration file cannot grant it
+func isTrustRequired(config *Configuration, cache *Cache) bool {
+	return config.RequireTrust || cache.RequireTrust
+}
+
+// Returns every origin the tool is downloaded from. Tools with a direct URL
+// also request their version from the host of the version_url.
+func getToolOrigins(tool *Tool) []string {
+	result := []string{getToolLink(tool)}
+
+	if tool.UrlTemplate != "" && tool.VersionUrl != "" {
+		if host := getUrlHost(tool.VersionUrl); host != result[0] {
+			result = append(result, host)
+		}
+	}
+
+	return result
+}
+
+func checkTrust(name string, tool *Tool, config *Configuration, cache *Cache) error {
+	if !isTrustRequired(config, cache) {
+		return nil
+	}
+
+	for _, origin := range getToolOrigins(tool) {
+		if !cache.Trusted[origin] {
+			//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+			return fmt.Errorf("Tool '%s' is downloaded from '%s', which is not trusted yet. Review it and run 'tooli trust %s' to allow it.", name, origin, origin)
+		}
+	}
+
+	return nil
+}
+
+// Trusts the given origins, or the origins of the given tools. Without any
+// arguments, the untrusted origins of the configured tools are listed. Remote
+// configuration locations are trusted without reading the configuration, as
+// it cannot be loaded before they are trusted.
+func trustOrigins(configLocation *string, arguments []string, require bool) {
+	needsConfig := len(arguments) == 0 && !require
+	for _, argument := range arguments {
+		if !isRemoteLocation(argument) {
+			needsConfig = true
+		}
+	}
+
+	var config Configuration
+	if needsConfig {
+		var err error
+		config, err = getConfig(*configLocation)
+		if err != nil {
+			printConfigError(err)
+			os.Exit(1)
+		}
+	}
+
+	cache, err := getCache()
+	if err != nil {
+		logError("Failed to obtain cache. Message: %v", err)
+		os.Exit(1)
+	}
+
+	if require {
+		cache.RequireTrust = true
+		fmt.Println("Tools are only installed from trusted origins from now on.")
+	}
+
+	if len(arguments) == 0 && !require {
+		var untrusted []string
+		for name, tool := range config.Tools {
+			for _, origin := range getToolOrigins(&tool) {
+				if !cache.Trusted[origin] {
+					untrusted = append(untrusted, fmt.Sprintf("%s (%s)", origin, name))
+				}
+			}
+		}
+		sort.Strings(untrusted)
+
+		if len(untrusted) == 0 {
+			fmt.Println("All configured tools are trusted.")
+		}
+		for _, entry := range untrusted {
+			fmt.Println(entry)
+		}
+		return
+	}
+
+	for _, argument := range arguments {
+		origins := []string{argument}
+		if tool, found := config.Tools[argument]; found {
+			origins = getToolOrigins(&tool)
+		}
+
+		for _, origin := range origins {
+			cache.Trusted[origin] = true
+			fmt.Printf("Trusting '%s'.\n", origin)
+		}
+	}
+
+	err = cache.writeCache()
+	if err != nil {
+		logError("%v", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,335 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	uiActionNone    = ""
+	uiActionInstall = "install"
+	uiActionUpdate  = "update"
+	uiActionRemove  = "remove"
+)
+
+// Lines of the UI that are not tools, i.e. the help, the header and the status
+const uiReservedLines = 6
+
+type UiEntry struct {
+	Name      string
+	Installed string
+	Available string
+	Held      bool
+	// Set if the latest release could not be obtained
+	Error  string
+	Action string
+}
+
+// The action toggled by the space key: installing tools that are not
+// installed and updating outdated ones
+func (entry *UiEntry) getDefaultAction() string {
+	switch {
+	case entry.Installed == "" && entry.Error == "":
+		return uiActionInstall
+	case entry.Error == "" && !isSameVersion(entry.Installed, entry.Available):
+		return uiActionUpdate
+	default:
+		return uiActionNone
+	}
+}
+
+type ToolUi struct {
+	entries []UiEntry
+	cursor  int
+	// Index of the first visible entry
+	offset  int
+	message string
+}
+
+// Lists the enabled tools with their installed and latest versions
+func getUiEntries(downloader *Downloader, config *Configuration, cache *Cache) []UiEntry {
+	var names []string
+	for name, tool := range config.Tools {
+		if tool.isEnabled() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	downloader.prefetchLatestTags(config, names)
+
+	var mutex sync.Mutex
+	entries := make([]UiEntry, 0, len(names))
+	runParallel(names, config.getJobs(0), func(name string) {
+		tool := config.Tools[name]
+		entry := UiEntry{Name: name, Installed: cache.Tools[name], Held: cache.Held[name]}
+
+		release, err := getAvailableRelease(downloader, &tool)
+		if err != nil {
+			entry.Error = err.Error()
+		} else {
+			entry.Available = release.TagName
+		}
+
+		mutex.Lock()
+		entries = append(entries, entry)
+		mutex.Unlock()
+	})
+
+	sort.Slice(entries, func(i int, j int) bool { return entries[i].Name < entries[j].Name })
+
+	return entries
+}
+
+func (ui *ToolUi) draw() {
+	var screen strings.Builder
+	screen.WriteString("\x1b[H\x1b[2J")
+	screen.WriteString("Up/down: move   space: install/update   r: remove   a: mark all updates   enter: apply   q: quit\n\n")
+
+	nameSize := 4
+	installedSize := 9
+	availableSize := 9
+	for _, entry := range ui.entries {
+		nameSize = max(nameSize, len(entry.Name))
+		installedSize = max(installedSize, len(entry.Installed))
+		availableSize = max(availableSize, len(entry.Available))
+	}
+
+	fmt.Fprintf(&screen, "  %-7s  %-*s  %-*s  %-*s\n", "Action", nameSize, "Name", installedSize, "Installed", availableSize, "Available")
+
+	visible := getTerminalHeight() - uiReservedLines
+	if visible < 1 {
+		visible = 1
+	}
+	if ui.cursor < ui.offset {
+		ui.offset = ui.cursor
+	} else if ui.cursor >= ui.offset+visible {
+		ui.offset = ui.cursor - visible + 1
+	}
+
+	for i := ui.offset; i < len(ui.entries) && i < ui.offset+visible; i++ {
+		entry := &ui.entries[i]
+
+		available := entry.Available
+		if entry.Error != "" {
+			available = "unavailable"
+		}
+		note := ""
+		if entry.Held {
+			note = "(held)"
+		}
+
+		line := fmt.Sprintf("  %-7s  %-*s  %-*s  %-*s  %s", entry.Action, nameSize, entry.Name, installedSize, entry.Installed, availableSize, available, note)
+		if i == ui.cursor {
+			line = "\x1b[7m" + line + "\x1b[0m"
+		}
+		screen.WriteString(line + "\n")
+	}
+
+	if ui.cursor < len(ui.entries) && ui.entries[ui.cursor].Error != "" {
+		fmt.Fprintf(&screen, "\n%s", ui.entries[ui.cursor].Error)
+	} else if ui.message != "" {
+		fmt.Fprintf(&screen, "\n%s", ui.message)
+	}
+
+	fmt.Print(screen.String())
+}
+
+// Handles a key press, returns whether the UI is done and whether the marked
+// actions are to be applied
+func (ui *ToolUi) handleKey(key string) (bool, bool) {
+	ui.message = ""
+	if len(ui.entries) == 0 {
+		return key == "q" || key == "\x1b" || key == "\x03", false
+	}
+
+	entry := &ui.entries[ui.cursor]
+	switch key {
+	case "\x1b[A", "k":
+		if ui.cursor > 0 {
+			ui.cursor--
+		}
+	case "\x1b[B", "j":
+		if ui.cursor < len(ui.entries)-1 {
+			ui.cursor++
+		}
+	case " ":
+		action := entry.getDefaultAction()
+		if action == uiActionNone {
+			ui.message = fmt.Sprintf("'%s' is up to date.", entry.Name)
+		} else if entry.Action == action {
+			entry.Action = uiActionNone
+		} else {
+			entry.Action = action
+		}
+	case "r":
+		if entry.Installed == "" {
+			ui.message = fmt.Sprintf("'%s' is not installed.", entry.Name)
+		} else if entry.Action == uiActionRemove {
+			entry.Action = uiActionNone
+		} else {
+			entry.Action = uiActionRemove
+		}
+	case "a":
+		for i := range ui.entries {
+			if ui.entries[i].getDefaultAction() == uiActionUpdate && !ui.entries[i].Held {
+				ui.entries[i].Action = uiActionUpdate
+			}
+		}
+	case "\r", "\n":
+		return true, true
+	case "q", "\x1b", "\x03":
+		return true, false
+	}
+
+	return false, false
+}
+
+// Shows the UI until the user applies or quits, returns whether to apply
+func (ui *ToolUi) run() (bool, error) {
+	restore, err := enableRawInput()
+	if err != nil {
+		return false, err
+	}
+	defer restore()
+
+	fmt.Print("\x1b[?25l")
+	defer fmt.Print("\x1b[?25h\x1b[H\x1b[2J")
+
+	buffer := make([]byte, 16)
+	for {
+		ui.draw()
+
+		n, err := os.Stdin.Read(buffer)
+		if err != nil {
+			return false, err
+		}
+
+		done, apply := ui.handleKey(string(buffer[:n]))
+		if done {
+			return apply, nil
+		}
+	}
+}
+
+//lint:ignore ST1005 The error is shown to the user as a sentence.
+var errNoInstalledFiles = errors.New("No installed files are recorded, use 'tooli prune' to find and delete its binaries.")
+
+// Deletes the installed files of a tool and its entries in the cache. Tools
+// installed before files were recorded fall back to the configured binaries,
+// and if there are none either, the cache entry is kept.
+func uninstallTool(name string, config *Configuration, cache *Cache) error {
+	files := cache.Installed[name].Files
+	if len(files) == 0 {
+		files = getBinaryFileNames(config.Tools[name])
+	}
+	if len(files) == 0 {
+		return errNoInstalledFiles
+	}
+
+	for _, file := range files {
+		err := os.Remove(getInstalledFilePath(config.InstallationDirectory, file))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	toolDirectory, err := getToolDirectory(name)
+	if err == nil && filepath.Base(toolDirectory) == name {
+		err = removeDirectoryInUse(toolDirectory)
+		if err != nil {
+			return err
+		}
+	}
+
+	delete(cache.Tools, name)
+	delete(cache.Installed, name)
+	delete(cache.Held, name)
+
+	return nil
+}
+
+// Lists the configured tools in the terminal, lets the user mark tools to
+// install, update or remove and then does so with the usual progress output
+func runToolUi(configLocation *string, downloadTimeout int) {
+	if !isInteractive() {
+		logError("'tooli ui' needs a terminal, use the other commands in scripts.")
+		os.Exit(1)
+	}
+
+	config, err := getConfig(*configLocation)
+	if err != nil {
+		printConfigError(err)
+		os.Exit(1)
+	}
+
+	cache, err := getCache()
+	if err != nil {
+		logError("Failed to obtain cache. Message: %v", err)
+		os.Exit(1)
+	}
+
+	downloader, err := newDownloader(downloadTimeout, &config)
+	if err != nil {
+		logError("%v", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("Checking for updates...")
+	ui := ToolUi{entries: getUiEntries(&downloader, &config, &cache)}
+
+	apply, err := ui.run()
+	if err != nil {
+		logError("%v", err)
+		os.Exit(1)
+	}
+	if !apply {
+		return
+	}
+
+	var installs []string
+	var results []ToolResult
+	removed := false
+	for _, entry := range ui.entries {
+		switch entry.Action {
+		case uiActionInstall, uiActionUpdate:
+			installs = append(installs, entry.Name)
+		case uiActionRemove:
+			err = uninstallTool(entry.Name, &config, &cache)
+			if err != nil {
+				logError("Could not remove tool '%s': %v", entry.Name, err)
+				results = append(results, ToolResult{Tool: entry.Name, Action: actionFailed, OldVersion: entry.Installed, Error: err.Error()})
+				continue
+			}
+			fmt.Printf("Removed tool '%s'.\n", entry.Name)
+			results = append(results, ToolResult{Tool: entry.Name, Action: actionRemoved, OldVersion: entry.Installed})
+			removed = true
+		}
+	}
+
+	if removed {
+		err = cache.writeCache()
+		if err != nil {
+			logError("%v", err)
+			os.Exit(1)
+		}
+	}
+
+	if len(installs) == 0 {
+		if jsonOutput != nil {
+			writeToolResults(results)
+		} else if !removed {
+			fmt.Println("Nothing to do.")
+		}
+		return
+	}
+
+	installTools(configLocation, InstallToolsOptions{ToolSpecs: installs, DownloadTimeout: downloadTimeout, Results: results})
+}
@@ -0,0 +1,191 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+var repositoryPartRegex = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// Returns the problems of a single tool that would only surface once it is installed
+func getToolProblems(name string, tool *Tool) []string {
+	var result []string
+	add := func(format string, args ...any) {
+		result = append(result, fmt.Sprintf("Tool '%s': ", name)+fmt.Sprintf(format, args...))
+	}
+
+	if tool.UrlTemplate == "" && tool.OciImage == "" && tool.Gist == "" {
+		if tool.Owner == "" {
+			add("'owner' is missing")
+		} else if !repositoryPartRegex.MatchString(tool.Owner) {
+			add("'owner' '%s' is not a valid owner name", tool.Owner)
+		}
+
+		if tool.Repository == "" {
+			add("'repository' is missing")
+		} else if !repositoryPartRegex.MatchString(tool.Repository) {
+			add("'repository' '%s' is not a valid repository name", tool.Repository)
+		}
+	}
+
+	if tool.UrlTemplate != "" && tool.VersionUrl == "" && tool.Version == "" {
+		add("tools with a 'url_template' also need a 'version_url'")
+	}
+
+	if tool.VersionRegex != "" {
+		_, err := regexp.Compile(tool.VersionRegex)
+		if err != nil {
+			add("'version_regex' does not compile: %v", err)
+		}
+	}
+
+	for _, pattern := range tool.IgnoreVersions {
+		_, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			add("'ignore_versions' entry '%s' does not compile: %v", pattern, err)
+		}
+	}
+
+	if tool.VersionConstraint != "" {
+		_, err := parseVersionConstraint(tool.VersionConstraint)
+		if err != nil {
+			add("'version_constraint' is invalid: %v", err)
+		}
+	}
+
+	if len(tool.Binaries) == 0 && tool.InstallMode != installModeInstaller {
+		add("no binaries are configured")
+	}
+
+	for _, binary := range tool.Binaries {
+		if binary.Name == "" {
+			add("a binary has no name")
+			continue
+		}
+
+		_, err := path.Match(binary.Name, "")
+		if err != nil {
+			add("binary name '%s' is not a valid pattern", binary.Name)
+		}
+	}
+
+	if tool.UrlTemplate == "" && tool.BuildCommand == "" && tool.OciImage == "" && tool.Gist == "" {
+		asset, err := getPlatformAsset(tool)
+		if err == nil && asset == "" {
+			add("no asset is configured for %s", runtime.GOOS)
+		}
+	}
+
+	return result
+}
+
+// Returns a problem for every binary name that is installed by more than one
+// binary, as the later one would overwrite the earlier one
+func getDuplicateBinaryProblems(config *Configuration) []string {
+	var result []string
+	owners := make(map[string]string)
+
+	for _, name := range config.getToolNames() {
+		for _, binary := range config.Tools[name].Binaries {
+			output := binary.RenameTo
+			if output == "" {
+				output = path.Base(binary.Name)
+			}
+
+			key := output
+			if runtime.GOOS == "windows" {
+				key = strings.ToLower(key)
+			}
+
+			owner, found := owners[key]
+			switch {
+			case !found:
+				owners[key] = name
+			case owner == name:
+				result = append(result, fmt.Sprintf("Tool '%s': installs more than one binary named '%s'", name, output))
+			default:
+				result = append(result, fmt.Sprintf("Tools '%s' and '%s' both install a binary named '%s'", owner, name, output))
+			}
+		}
+	}
+
+	return result
+}
+
+// Checks that every tool's release source can be reached
+func getOnlineProblems(config *Configuration, downloadTimeout int) []string {
+	downloader, err := newDownloader(downloadTimeout, config)
+	if err != nil {
+		return []string{fmt.Sprintf("Could not create the HTTP client: %v", err)}
+	}
+
+	var result []string
+	for _, name := range config.getToolNames() {
+		tool := config.Tools[name]
+		if tool.Version != "" && tool.UrlTemplate != "" {
+			continue
+		}
+
+		source, err := downloader.getSource(&tool)
+		if err == nil {
+			_, err = source.GetLatest()
+		}
+		if err != nil {
+			result = append(result, fmt.Sprintf("Tool '%s': could not reach '%s': %v", name, getToolLink(&tool), err))
+		}
+	}
+
+	return result
+}
+
+// Returns all problems of the configuration that can be found without network access
+func getConfigurationProblems(config *Configuration) []string {
+	var result []string
+	for _, err := range config.getErrors() {
+		result = append(result, err.Error())
+	}
+
+	for _, name := range config.getToolNames() {
+		tool := config.Tools[name]
+		result = append(result, getToolProblems(name, &tool)...)
+	}
+
+	return append(result, getDuplicateBinaryProblems(config)...)
+}
+
+// Checks the configuration and reports all problems at once, returns whether
+// the configuration is valid
+func validateConfiguration(configLocation *string, online bool, downloadTimeout int) bool {
+	config, err := loadConfiguration(*configLocation)
+	if err != nil {
+		logError("Could not load configuration: %v.", err)
+		return false
+	}
+
+	problems := getConfigurationProblems(&config)
+
+	if online {
+		if len(config.getErrors()) == 0 {
+			problems = append(problems, getOnlineProblems(&config, downloadTimeout)...)
+		} else {
+			logWarning("Skipping the online checks because the configuration is invalid.")
+		}
+	}
+
+	if len(problems) == 0 {
+		fmt.Println("The configuration is valid.")
+		return true
+	}
+
+	for _, problem := range problems {
+		fmt.Printf("- %s\n", problem)
+	}
+	fmt.Printf("Found %d problems.\n", len(problems))
+
+	return false
+}
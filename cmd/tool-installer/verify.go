@@ -0,0 +1,107 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+func hashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Returns the SHA-256 digests of the installed files, keyed by their names
+func hashInstalledFiles(files []string, installDirectory string) (map[string]string, error) {
+	result := make(map[string]string)
+
+	for _, file := range files {
+		digest, err := hashFile(getInstalledFilePath(installDirectory, file))
+		if err != nil {
+			return nil, err
+		}
+		result[file] = digest
+	}
+
+	return result, nil
+}
+
+// Checks that the installed files of the given (or all) tools still have the
+// digests recorded at installation, returns false if any do not
+func verifyInstalledTools(configLocation *string, names []string) bool {
+	config, err := getConfig(*configLocation)
+	if err != nil {
+		printConfigError(err)
+		os.Exit(1)
+	}
+
+	cache, err := getCache()
+	if err != nil {
+		logError("Failed to obtain cache. Message: %v", err)
+		os.Exit(1)
+	}
+
+	if len(names) == 0 {
+		for name := range cache.Tools {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+
+	ok := true
+	for _, name := range names {
+		if _, found := cache.Tools[name]; !found {
+			fmt.Printf("%s: not installed\n", name)
+			ok = false
+			continue
+		}
+
+		installed := cache.Installed[name]
+		if len(installed.Files) == 0 {
+			fmt.Printf("%s: no files recorded, reinstall it to enable verification\n", name)
+			continue
+		}
+
+		for _, file := range installed.Files {
+			expected, found := installed.FileHashes[file]
+			if !found {
+				fmt.Printf("%s: %s has no recorded digest, reinstall it to enable verification\n", name, file)
+				continue
+			}
+
+			digest, err := hashFile(getInstalledFilePath(config.InstallationDirectory, file))
+			if os.IsNotExist(err) {
+				fmt.Printf("%s: %s is missing\n", name, file)
+				ok = false
+			} else if err != nil {
+				fmt.Printf("%s: %s could not be read: %v\n", name, file, err)
+				ok = false
+			} else if digest != expected {
+				fmt.Printf("%s: %s was modified\n", name, file)
+				ok = false
+			}
+		}
+	}
+
+	if ok {
+		fmt.Println("All installed files are unmodified.")
+	}
+
+	return ok
+}
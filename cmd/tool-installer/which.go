@@ -0,0 +1,63 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+)
+
+// Returns the paths of the installed binaries of a tool, from the files
+// recorded at installation or, for tools installed before files were
+// recorded, from the configured binaries
+func getInstalledBinaryPaths(name string, config *Configuration, cache *Cache) []string {
+	var result []string
+	for _, file := range cache.Installed[name].Files {
+		// Absolute files are auxiliary files like completions
+		if !filepath.IsAbs(file) {
+			result = append(result, filepath.Join(config.InstallationDirectory, file))
+		}
+	}
+	if len(result) > 0 {
+		return result
+	}
+
+	for file := range getConfiguredFileNames(map[string]Tool{name: config.Tools[name]}, &Cache{}) {
+		result = append(result, filepath.Join(config.InstallationDirectory, file))
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+// Prints the installed version and the paths of the binaries of a tool
+func printToolLocation(configLocation *string, name string) error {
+	config, err := getConfig(*configLocation)
+	if err != nil {
+		return err
+	}
+
+	cache, err := getCache()
+	if err != nil {
+		return err
+	}
+
+	_, configured := config.Tools[name]
+	version, installed := cache.Tools[name]
+	if !installed && !configured {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("Tool '%s' not found in configuration.", name)
+	}
+	if !installed {
+		//lint:ignore ST1005 End-user facing messages should be nice, ST1005 is not nice.
+		return fmt.Errorf("Tool '%s' is configured but not installed, install it with 'tooli install %s'.", name, name)
+	}
+
+	fmt.Printf("%s %s\n", name, version)
+	for _, path := range getInstalledBinaryPaths(name, &config, &cache) {
+		fmt.Println(path)
+	}
+
+	return nil
+}
@@ -1,3 +1,25 @@
 module github.com/ageh/tool-installer
 
-go 1.23
+go 1.23.0
+
+require (
+	github.com/BurntSushi/toml v1.5.0
+	github.com/ProtonMail/go-crypto v1.5.1
+	github.com/bodgit/sevenzip v1.6.1
+	github.com/klauspost/compress v1.17.11
+	github.com/ulikunitz/xz v0.5.17
+	golang.org/x/crypto v0.41.0
+	golang.org/x/sys v0.35.0
+)
+
+require (
+	github.com/andybalholm/brotli v1.1.1 // indirect
+	github.com/bodgit/plumbing v1.3.0 // indirect
+	github.com/bodgit/windows v1.0.1 // indirect
+	github.com/cloudflare/circl v1.6.3 // indirect
+	github.com/hashicorp/golang-lru/v2 v2.0.7 // indirect
+	github.com/pierrec/lz4/v4 v4.1.22 // indirect
+	github.com/spf13/afero v1.11.0 // indirect
+	go4.org v0.0.0-20200411211856-f5505b9728dd // indirect
+	golang.org/x/text v0.28.0 // indirect
+)